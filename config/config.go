@@ -25,7 +25,7 @@ import (
 	"path/filepath"
 	"strings"
 
-	yaml "gopkg.in/yaml.v1"
+	yaml "gopkg.in/yaml.v2"
 
 	flags "github.com/jessevdk/go-flags"
 	logging "github.com/op/go-logging"
@@ -38,21 +38,26 @@ const (
 	defaultSettingsFile = "settings.yaml"
 	defaultProjectURL   = "github.com/myuser/myproject"
 	defaultAPIVersion   = "v1"
+	defaultDialect      = "sqlite3"
 )
 
 // Options type holding possible cli params
 type Options struct {
 	Args struct {
-		TypesFile string `positional-arg-name:"types_file"`
+		TypesFiles []string `positional-arg-name:"types" description:"Go files, directories or package import paths with the struct types to generate code for" required:"1"`
 	} `positional-args:"yes" required:"yes"`
 
 	Verbose []bool `short:"v" long:"verbose" description:"Verbose output"`
 	//TypesFile   string `short:"t" long:"types" description:"File with struct types to consider for generating the skeletom code" required:"yes"`
-	Output      string `short:"o" long:"output" description:"Folder where building output structure of generated files"`
-	ProjectURL  string `short:"u" long:"url" description:"Url of this project. If not specified 'github.com/myproject' is used"`
-	APIVersion  string `short:"a" long:"apiversion" description:"Version of the REST api"`
-	Settings    string `short:"c" long:"config" description:"Settings file path"`
-	UserPlugins string `short:"p" long:"plugins" description:"Path to the folder with .so plugin files"`
+	Output      string   `short:"o" long:"output" description:"Folder where building output structure of generated files"`
+	ProjectURL  string   `short:"u" long:"url" description:"Url of this project. If not specified 'github.com/myproject' is used"`
+	APIVersion  string   `short:"a" long:"apiversion" description:"Version of the REST api"`
+	Settings    string   `short:"c" long:"config" description:"Settings file path"`
+	UserPlugins string   `short:"p" long:"plugins" description:"Path to the folder with .so plugin files"`
+	Dialect     string   `short:"d" long:"dialect" description:"SQL dialect of the generated datastore code" choice:"sqlite3" choice:"postgres" choice:"mysql"`
+	DryRun      bool     `long:"dry-run" description:"Show the changes to be done instead of writing them. Exits with error if there are pending changes"`
+	Only        []string `long:"only" description:"Generate code only for this type. Can be repeated"`
+	Exclude     []string `long:"exclude" description:"Do not generate code for this type. Can be repeated"`
 
 	// Options loaded from settings file
 	Version        string `yaml:"version"`
@@ -65,7 +70,7 @@ var Config Options
 
 // ValidateAndInitialize check received params and initialize default ones
 func (c *Options) ValidateAndInitialize() error {
-	if len(c.Args.TypesFile) == 0 {
+	if len(c.Args.TypesFiles) == 0 {
 		return &flags.Error{
 			Type:    flags.ErrHelp,
 			Message: "Types file not provided",
@@ -148,6 +153,10 @@ func (c *Options) setDefaultValuesWhenNeeded() error {
 		c.APIVersion = defaultAPIVersion
 	}
 
+	if len(c.Dialect) == 0 {
+		c.Dialect = defaultDialect
+	}
+
 	return nil
 }
 
@@ -171,9 +180,16 @@ func (c *Options) normalizePaths() error {
 		return err
 	}
 
-	err = io.NormalizePath(&c.Args.TypesFile)
-	if err != nil {
-		return err
+	for i, path := range c.Args.TypesFiles {
+		err = io.NormalizePath(&path)
+		if err != nil {
+			return err
+		}
+
+		// package import paths are not in disk and are kept as they are
+		if _, err := os.Stat(path); err == nil {
+			c.Args.TypesFiles[i] = path
+		}
 	}
 
 	err = io.NormalizePath(&c.UserPlugins)
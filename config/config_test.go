@@ -64,7 +64,7 @@ func (s *ConfigSuite) TestLoadConfig(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(Config.Settings, check.Equals, f.Name())
 	c.Assert(Config.Output, check.Equals, "")
-	c.Assert(Config.Args.TypesFile, check.Equals, "")
+	c.Assert(Config.Args.TypesFiles, check.HasLen, 0)
 	c.Assert(len(Config.Verbose), check.Equals, 0)
 	c.Assert(Config.Version, check.Equals, "1.2-3")
 	c.Assert(Config.TemplatesPath, check.Equals, "/local/path/templates")
@@ -131,3 +131,27 @@ func (s *ConfigSuite) TestCalculateProjectURL_notGopathSubdir(c *check.C) {
 	url := calculateProjectURL()
 	c.Assert(url, check.Equals, defaultProjectURL)
 }
+
+func (s *ConfigSuite) TestNormalizePaths_typesFiles(c *check.C) {
+	d, err := ioutil.TempDir("", "")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(d)
+
+	current, err := os.Getwd()
+	c.Assert(err, check.IsNil)
+	c.Assert(os.Chdir(d), check.IsNil)
+	defer os.Chdir(current)
+
+	c.Assert(os.Mkdir("model", 0755), check.IsNil)
+
+	options := Options{}
+	options.Args.TypesFiles = []string{"model", "github.com/myuser/myproject/model"}
+	c.Assert(options.normalizePaths(), check.IsNil)
+
+	dir, err := os.Getwd()
+	c.Assert(err, check.IsNil)
+	c.Assert(options.Args.TypesFiles, check.DeepEquals, []string{
+		filepath.Join(dir, "model"),
+		"github.com/myuser/myproject/model",
+	})
+}
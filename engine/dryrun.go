@@ -0,0 +1,127 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	stdio "io"
+	"os"
+
+	"github.com/rmescandon/cruder/io"
+)
+
+const (
+	statusCreate = "create"
+	statusMerge  = "merge"
+	statusSkip   = "skip"
+)
+
+// dryRun keeps in memory the output makers would write to disk, so that
+// following makers targeting the same file see the pending changes
+type dryRun struct {
+	// paths in order of generation
+	paths []string
+	// nil content for outputs the makers skipped
+	outputs map[string][]byte
+}
+
+func newDryRun() *dryRun {
+	return &dryRun{outputs: make(map[string][]byte)}
+}
+
+// current returns the pending output for a file path, if any
+func (d *dryRun) current(path string) (*io.Content, bool, error) {
+	b, ok := d.currentBytes(path)
+	if !ok {
+		return nil, false, nil
+	}
+
+	content, err := io.NewContent(string(b))
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+// currentBytes returns the raw pending output for a file path, if any
+func (d *dryRun) currentBytes(path string) ([]byte, bool) {
+	b := d.outputs[path]
+	return b, b != nil
+}
+
+// write stores the output for a file path instead of writing it to disk
+func (d *dryRun) write(path string, content *io.Content) error {
+	b, err := content.Bytes()
+	if err != nil {
+		return err
+	}
+
+	d.writeBytes(path, b)
+	return nil
+}
+
+// writeBytes stores raw content for a file path instead of writing it to disk
+func (d *dryRun) writeBytes(path string, b []byte) {
+	d.add(path)
+	d.outputs[path] = b
+}
+
+// skip records a file path makers did not generate output for
+func (d *dryRun) skip(path string) {
+	d.add(path)
+}
+
+func (d *dryRun) add(path string) {
+	if _, ok := d.outputs[path]; !ok {
+		d.paths = append(d.paths, path)
+		d.outputs[path] = nil
+	}
+}
+
+// report writes the status of every output file and the differences with
+// existing ones. Returns true if there are changes pending to be written
+func (d *dryRun) report(w stdio.Writer) (bool, error) {
+	pending := false
+	for _, path := range d.paths {
+		generated := d.outputs[path]
+		if generated == nil {
+			fmt.Fprintf(w, "%v: %v\n", statusSkip, path)
+			continue
+		}
+
+		current, err := io.FileToByteArray(path)
+		switch {
+		case os.IsNotExist(err):
+			fmt.Fprintf(w, "%v: %v\n", statusCreate, path)
+			fmt.Fprint(w, io.UnifiedDiff("/dev/null", path, "", string(generated)))
+			pending = true
+		case err != nil:
+			return pending, fmt.Errorf("Error reading %v: %v", path, err)
+		case bytes.Equal(current, generated):
+			fmt.Fprintf(w, "%v: %v\n", statusSkip, path)
+		default:
+			fmt.Fprintf(w, "%v: %v\n", statusMerge, path)
+			fmt.Fprint(w, io.UnifiedDiff(path, path, string(current), string(generated)))
+			pending = true
+		}
+	}
+	return pending, nil
+}
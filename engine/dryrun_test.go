@@ -0,0 +1,154 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package engine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+
+	check "gopkg.in/check.v1"
+)
+
+const (
+	dryRunMockName      = "dryrunmock"
+	dryRunFilesMockName = "dryrunfilesmock"
+)
+
+type DryRunSuite struct{}
+
+var _ = check.Suite(&DryRunSuite{})
+
+func (s *DryRunSuite) TearDownTest(c *check.C) {
+	dryRunOutputs = nil
+}
+
+func (s *DryRunSuite) TestReport(c *check.C) {
+	dir, err := ioutil.TempDir("", "cruder_test")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	unchanged := filepath.Join(dir, "unchanged.go")
+	changed := filepath.Join(dir, "changed.go")
+	created := filepath.Join(dir, "created.go")
+	skipped := filepath.Join(dir, "skipped.go")
+
+	c.Assert(io.StringToFile("package pkg\n", unchanged), check.IsNil)
+	c.Assert(io.StringToFile("package other\n", changed), check.IsNil)
+
+	content, err := io.NewContent("package pkg\n")
+	c.Assert(err, check.IsNil)
+
+	d := newDryRun()
+	c.Assert(d.write(unchanged, content), check.IsNil)
+	c.Assert(d.write(changed, content), check.IsNil)
+	c.Assert(d.write(created, content), check.IsNil)
+	d.skip(skipped)
+
+	var buf bytes.Buffer
+	pending, err := d.report(&buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(pending, check.Equals, true)
+	c.Assert(buf.String(), check.Equals, "skip: "+unchanged+"\n"+
+		"merge: "+changed+"\n"+
+		"--- "+changed+"\n+++ "+changed+"\n@@ -1,1 +1,1 @@\n-package other\n+package pkg\n"+
+		"create: "+created+"\n"+
+		"--- /dev/null\n+++ "+created+"\n@@ -0,0 +1,1 @@\n+package pkg\n"+
+		"skip: "+skipped+"\n")
+}
+
+func (s *DryRunSuite) TestReport_noChanges(c *check.C) {
+	d := newDryRun()
+	d.skip("/any/path")
+
+	var buf bytes.Buffer
+	pending, err := d.report(&buf)
+	c.Assert(err, check.IsNil)
+	c.Assert(pending, check.Equals, false)
+	c.Assert(buf.String(), check.Equals, "skip: /any/path\n")
+}
+
+func (s *DryRunSuite) TestProcessMaker_dryRun(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	m := &mockMaker{id: dryRunMockName}
+	makers.Register(m)
+	defer os.RemoveAll(m.basePath)
+
+	t, err := testdata.TestTemplate(dryRunMockName)
+	c.Assert(err, check.IsNil)
+
+	dryRunOutputs = newDryRun()
+	c.Assert(processMaker(h, t), check.IsNil)
+
+	// nothing is written to disk
+	_, err = os.Stat(m.OutputFilepath())
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+
+	// but is available for following makers
+	current, err := loadCurrentOutput(m.OutputFilepath())
+	c.Assert(err, check.IsNil)
+	c.Assert(current, check.NotNil)
+	c.Assert(current.Ast.Name.Name, check.Equals, "pkg")
+}
+
+type filesMockMaker struct {
+	mockMaker
+}
+
+func (m *filesMockMaker) Files() map[string][]byte {
+	return map[string][]byte{
+		filepath.Join(m.basePath, "b.sql"): []byte("b"),
+		filepath.Join(m.basePath, "a.sql"): []byte("a"),
+	}
+}
+
+func (s *DryRunSuite) TestProcessMaker_dryRunFiles(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	m := &filesMockMaker{mockMaker{id: dryRunFilesMockName}}
+	makers.Register(m)
+	// initializes the temporary base path
+	m.OutputFilepath()
+	defer os.RemoveAll(m.basePath)
+
+	t, err := testdata.TestTemplate(dryRunFilesMockName)
+	c.Assert(err, check.IsNil)
+
+	dryRunOutputs = newDryRun()
+	c.Assert(processMaker(h, t), check.IsNil)
+
+	c.Assert(dryRunOutputs.paths, check.DeepEquals, []string{
+		m.OutputFilepath(),
+		filepath.Join(m.basePath, "a.sql"),
+		filepath.Join(m.basePath, "b.sql"),
+	})
+	c.Assert(string(dryRunOutputs.outputs[filepath.Join(m.basePath, "a.sql")]), check.Equals, "a")
+
+	_, err = os.Stat(filepath.Join(m.basePath, "a.sql"))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
@@ -21,8 +21,10 @@ package engine
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"plugin"
+	"sort"
 	"strings"
 
 	"github.com/rmescandon/cruder/config"
@@ -33,6 +35,10 @@ import (
 	"github.com/rmescandon/cruder/parser"
 )
 
+// dryRunOutputs holds the generated output instead of writing it to disk
+// when running in dry run mode. It is nil otherwise
+var dryRunOutputs *dryRun
+
 // Run generates the code, based on loaded configuration and available templates
 func Run() error {
 	log.Info("Generating code...")
@@ -44,14 +50,28 @@ func Run() error {
 		return err
 	}
 
-	source, err := io.NewGoFile(config.Config.Args.TypesFile)
+	sources, err := loadSources(config.Config.Args.TypesFiles)
 	if err != nil {
-		return fmt.Errorf("Error reading go source file: %v", err)
+		return fmt.Errorf("Error reading go source files: %v", err)
 	}
 
-	typeHolders, err := parser.ComposeTypeHolders(source)
+	typeHolders, err := parser.ComposeTypeHolders(sources...)
 	if err != nil {
-		return fmt.Errorf("Error composing type holders from types file: %v", err)
+		return fmt.Errorf("Error composing type holders from types files: %v", err)
+	}
+
+	typeHolders, err = parser.SelectTypeHolders(typeHolders, config.Config.Only, config.Config.Exclude)
+	if err != nil {
+		return err
+	}
+
+	dialect, err := parser.NewDialect(config.Config.Dialect)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range typeHolders {
+		h.Dialect = dialect
 	}
 
 	templates, err := availableTemplates()
@@ -59,9 +79,43 @@ func Run() error {
 		return fmt.Errorf("Error listing available templates: %v", err)
 	}
 
-	processMakers(typeHolders, templates)
+	dryRunOutputs = nil
+	if config.Config.DryRun {
+		dryRunOutputs = newDryRun()
+	}
 
-	return nil
+	makersErr := processMakers(typeHolders, templates)
+
+	if dryRunOutputs != nil {
+		pending, err := dryRunOutputs.report(os.Stdout)
+		if err != nil {
+			return err
+		}
+		if pending && makersErr == nil {
+			return errs.ErrChangesPending
+		}
+	}
+
+	return makersErr
+}
+
+// loadSources parses the Go files found in the given files, directories or
+// packages, sharing the same file set
+func loadSources(paths []string) ([]*io.GoFile, error) {
+	files, err := io.GoFilePaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []*io.GoFile
+	for _, f := range files {
+		source, err := io.NewGoFile(f)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
 }
 
 func loadPlugins() error {
@@ -94,18 +148,31 @@ func availableTemplates() ([]string, error) {
 	return filepath.Glob(filepath.Join(config.Config.TemplatesPath, "*.template"))
 }
 
-func processMakers(holders []*parser.TypeHolder, templates []string) {
+// processMakers runs the makers of the templates for every type. Failing
+// makers do not stop the rest, but their errors are returned together
+func processMakers(holders []*parser.TypeHolder, templates []string) error {
+	failed := []error{}
 	for _, t := range templates {
 		log.Infof("Found template: %v", filepath.Base(t))
 		for _, h := range holders {
 			err := processMaker(h, t)
-			if err != nil {
-				// warn of the error but continue with next maker
+			switch err.(type) {
+			case nil:
+			case errs.ErrOutputExists:
+				// existing outputs are kept as they are
 				log.Warning(err)
+			default:
+				log.Error(err)
+				failed = append(failed, err)
 			}
 		}
 
 	}
+
+	if len(failed) > 0 {
+		return errs.NewErrMakersFailed(failed)
+	}
+	return nil
 }
 
 func processMaker(typeHolder *parser.TypeHolder, template string) error {
@@ -126,46 +193,156 @@ func processMaker(typeHolder *parser.TypeHolder, template string) error {
 		return err
 	}
 
+	if textMaker, ok := maker.(makers.TextMaker); ok {
+		return processTextMaker(textMaker, merged)
+	}
+
 	generatedOutput, err := io.NewContent(merged)
 	if err != nil {
 		return err
 	}
 
-	var currentOutput *io.Content
-	currentOutputFile, err := io.NewGoFile(maker.OutputFilepath())
+	currentOutput, err := loadCurrentOutput(maker.OutputFilepath())
 	if err != nil {
-		switch err.(type) {
-		case errs.ErrNotFound:
-			currentOutput = nil
-		default:
-			return err
-		}
-	} else {
-		currentOutput = &currentOutputFile.Content
+		return err
 	}
 
 	result, err := maker.Make(generatedOutput, currentOutput)
 	if err != nil {
+		if _, ok := err.(errs.ErrOutputExists); ok && dryRunOutputs != nil {
+			dryRunOutputs.skip(maker.OutputFilepath())
+			return nil
+		}
 		return err
 	}
 
-	if result != nil {
-		err = io.EnsureDir(filepath.Dir(maker.OutputFilepath()))
-		if err != nil {
-			return err
+	err = writeOutput(maker.OutputFilepath(), result)
+	if err != nil {
+		return err
+	}
+
+	if filesMaker, ok := maker.(makers.FilesMaker); ok {
+		return writeFiles(filesMaker.Files())
+	}
+
+	return nil
+}
+
+// processTextMaker makes and writes the output of a maker whose template is
+// not Go code
+func processTextMaker(maker makers.TextMaker, generatedOutput string) error {
+	path := maker.OutputFilepath()
+
+	currentOutput, err := loadCurrentText(path)
+	if err != nil {
+		return err
+	}
+
+	result, err := maker.MakeText([]byte(generatedOutput), currentOutput)
+	if err != nil {
+		if _, ok := err.(errs.ErrOutputExists); ok && dryRunOutputs != nil {
+			dryRunOutputs.skip(path)
+			return nil
+		}
+		return err
+	}
+
+	if result == nil {
+		if dryRunOutputs != nil {
+			dryRunOutputs.skip(path)
+		}
+		return nil
+	}
+
+	return writeFiles(map[string][]byte{path: result})
+}
+
+// writeOutput writes the result of a maker, if any, to its output file
+func writeOutput(path string, result *io.Content) error {
+	if dryRunOutputs != nil {
+		if result == nil {
+			dryRunOutputs.skip(path)
+			return nil
+		}
+		return dryRunOutputs.write(path, result)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err := result.ToFile(path)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Generated: %v", path)
+	return nil
+}
+
+// writeFiles writes other files generated by a maker, sorted by path
+func writeFiles(files map[string][]byte) error {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if dryRunOutputs != nil {
+			dryRunOutputs.writeBytes(path, files[path])
+			continue
 		}
 
-		err = io.ASTToFile(result.Ast, maker.OutputFilepath())
+		err := io.ByteArrayToFile(files[path], path)
 		if err != nil {
 			return err
 		}
 
-		log.Infof("Generated: %v", maker.OutputFilepath())
+		log.Infof("Generated: %v", path)
 	}
 
 	return nil
 }
 
+// loadCurrentOutput returns the existing content of an output file, or nil if
+// it does not exist yet. In dry run mode, pending output has precedence
+func loadCurrentOutput(path string) (*io.Content, error) {
+	if dryRunOutputs != nil {
+		content, ok, err := dryRunOutputs.current(path)
+		if err != nil || ok {
+			return content, err
+		}
+	}
+
+	currentOutputFile, err := io.NewGoFile(path)
+	if err != nil {
+		switch err.(type) {
+		case errs.ErrNotFound:
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+	return &currentOutputFile.Content, nil
+}
+
+// loadCurrentText returns the existing content of a non Go output file, or nil
+// if it does not exist yet. In dry run mode, pending output has precedence
+func loadCurrentText(path string) ([]byte, error) {
+	if dryRunOutputs != nil {
+		if b, ok := dryRunOutputs.currentBytes(path); ok {
+			return b, nil
+		}
+	}
+
+	b, err := io.FileToByteArray(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
 // Merges type, config and template, returning the result as a string
 func merge(typeHolder *parser.TypeHolder, templateFilepath string) (string, error) {
 	log.Debugf("Loading template: %v", filepath.Base(templateFilepath))
@@ -174,9 +351,11 @@ func merge(typeHolder *parser.TypeHolder, templateFilepath string) (string, erro
 		return "", fmt.Errorf("Error reading template file: %v", err)
 	}
 
-	replacedStr := typeHolder.ReplaceInTemplate(templateContent)
-	replacedStr = config.Config.ReplaceInTemplate(replacedStr)
+	if !isLegacyTemplate(templateContent) {
+		return execute(typeHolder, filepath.Base(templateFilepath), templateContent)
+	}
 
+	replacedStr := replaceMarks(typeHolder, templateContent)
 	if strings.Contains(replacedStr, "_#") || strings.Contains(replacedStr, "#_") {
 		return replacedStr, fmt.Errorf("%v type did not replace all %v template symbols",
 			typeHolder.Name, filepath.Base(templateFilepath))
@@ -21,11 +21,13 @@ package engine
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
 	"github.com/rmescandon/cruder/io"
 	"github.com/rmescandon/cruder/log"
 	"github.com/rmescandon/cruder/makers"
@@ -61,9 +63,12 @@ const (
 	}
 	`
 
-	mockName       = "mock"
-	mock2Name      = "mock2"
-	mockOutputpath = "mock/path"
+	mockName         = "mock"
+	mock2Name        = "mock2"
+	textMockName     = "textmock"
+	failingMockName  = "failingmock"
+	existingMockName = "existingmock"
+	mockOutputpath   = "mock/path"
 )
 
 type mockMaker struct {
@@ -93,6 +98,31 @@ func (m *mockMaker) Make(g *io.Content, c *io.Content) (*io.Content, error) {
 
 func (m *mockMaker) SetTypeHolder(*parser.TypeHolder) {}
 
+type textMockMaker struct {
+	mockMaker
+}
+
+// MakeText appends generated output to current one
+func (m *textMockMaker) MakeText(g []byte, c []byte) ([]byte, error) {
+	return append(c, g...), nil
+}
+
+type failingMockMaker struct {
+	mockMaker
+}
+
+func (m *failingMockMaker) Make(g *io.Content, c *io.Content) (*io.Content, error) {
+	return nil, errs.ErrNoContent
+}
+
+type existingMockMaker struct {
+	mockMaker
+}
+
+func (m *existingMockMaker) Make(g *io.Content, c *io.Content) (*io.Content, error) {
+	return nil, errs.NewErrOutputExists(m.OutputFilepath())
+}
+
 func newMockMaker(id string) *mockMaker {
 	return &mockMaker{id: id}
 }
@@ -112,7 +142,7 @@ func (s *EngineSuite) TestMerge(c *check.C) {
 	io.NormalizePath(&config.Config.TemplatesPath)
 	templates, err := availableTemplates()
 	c.Assert(err, check.IsNil)
-	c.Assert(templates, check.HasLen, 8)
+	c.Assert(templates, check.HasLen, 27)
 
 	config.Config.ProjectURL = "server.dom/namespace/project"
 	config.Config.APIVersion = "v1.0"
@@ -175,5 +205,71 @@ func (s *EngineSuite) TestProcessMakers(c *check.C) {
 	c.Assert(err, check.IsNil)
 	templates := []string{t, t2}
 
-	processMakers([]*parser.TypeHolder{h}, templates)
+	c.Assert(processMakers([]*parser.TypeHolder{h}, templates), check.IsNil)
+}
+
+func (s *EngineSuite) TestProcessMakers_failed(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	makers.Register(&failingMockMaker{mockMaker{id: failingMockName}})
+
+	t, err := testdata.TestTemplate(failingMockName)
+	c.Assert(err, check.IsNil)
+
+	err = processMakers([]*parser.TypeHolder{h}, []string{t, t})
+	c.Assert(err, check.DeepEquals, errs.NewErrMakersFailed([]error{errs.ErrNoContent, errs.ErrNoContent}))
+}
+
+func (s *EngineSuite) TestProcessMakers_outputExists(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	makers.Register(&existingMockMaker{mockMaker{id: existingMockName}})
+
+	t, err := testdata.TestTemplate(existingMockName)
+	c.Assert(err, check.IsNil)
+
+	// skipping existing outputs is not a failure
+	c.Assert(processMakers([]*parser.TypeHolder{h}, []string{t}), check.IsNil)
+}
+
+func (s *EngineSuite) TestProcessMaker_textMaker(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	m := &textMockMaker{mockMaker{id: textMockName}}
+	makers.Register(m)
+	// initializes the temporary base path
+	m.OutputFilepath()
+	defer os.RemoveAll(m.basePath)
+
+	t, err := testdata.TestTemplate(textMockName)
+	c.Assert(err, check.IsNil)
+
+	template, err := io.FileToString(t)
+	c.Assert(err, check.IsNil)
+
+	// template is not parsed as go code, and current output is passed to next call
+	c.Assert(processMaker(h, t), check.IsNil)
+	c.Assert(processMaker(h, t), check.IsNil)
+
+	output, err := io.FileToString(m.OutputFilepath())
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, replaceMarks(h, template)+replaceMarks(h, template))
+}
+
+func (s *EngineSuite) TestLoadSources(c *check.C) {
+	dir, err := ioutil.TempDir("", "")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	c.Assert(io.StringToFile("package model\n\ntype Book struct{}\n", filepath.Join(dir, "book.go")), check.IsNil)
+	c.Assert(io.StringToFile("package model\n\ntype Author struct{}\n", filepath.Join(dir, "author.go")), check.IsNil)
+
+	sources, err := loadSources([]string{dir})
+	c.Assert(err, check.IsNil)
+	c.Assert(sources, check.HasLen, 2)
+	c.Assert(sources[0].Path, check.Equals, filepath.Join(dir, "author.go"))
+	c.Assert(sources[1].Path, check.Equals, filepath.Join(dir, "book.go"))
 }
@@ -0,0 +1,136 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/parser"
+)
+
+// templateData is the model templates are executed against. The type holder
+// is embedded so that its fields and methods are reachable as {{.Name}},
+// {{.Fields}} or {{.IDFieldName}}
+type templateData struct {
+	*parser.TypeHolder
+	ProjectURL string
+	APIVersion string
+}
+
+// funcMap holds the helper functions available in templates
+var funcMap = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"lowerCamel": lowerCamel,
+	"snake":      snake,
+	"plural":     plural,
+	"sqlType":    parser.SQLType,
+}
+
+// isLegacyTemplate returns true for templates using _#MARK#_ placeholders
+func isLegacyTemplate(content string) bool {
+	return strings.Contains(content, "_#")
+}
+
+// replaceMarks replaces _#MARK#_ placeholders of legacy templates
+func replaceMarks(typeHolder *parser.TypeHolder, content string) string {
+	replaced := typeHolder.ReplaceInTemplate(content)
+	return config.Config.ReplaceInTemplate(replaced)
+}
+
+// execute renders a text/template template with type holder and config data
+func execute(typeHolder *parser.TypeHolder, name, content string) (string, error) {
+	// sql types depend on the dialect of the type
+	dialectFuncs := template.FuncMap{"sqlType": typeHolder.Dialect.SQLType}
+
+	t, err := template.New(name).Funcs(funcMap).Funcs(dialectFuncs).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing template %v: %v", name, err)
+	}
+
+	data := templateData{
+		TypeHolder: typeHolder,
+		ProjectURL: config.Config.ProjectURL,
+		APIVersion: config.Config.APIVersion,
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("Error executing template %v: %v", name, err)
+	}
+
+	return buf.String(), nil
+}
+
+// lowerCamel returns the identifier in camel case starting with lower case,
+// taking care of leading acronyms: "MyType" -> "myType", "HTTPServer" -> "httpServer"
+func lowerCamel(s string) string {
+	runes := []rune(s)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		// in an acronym followed by a word, last upper case starts the word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// snake returns the identifier in snake case: "MyType" -> "my_type",
+// "HTTPServer" -> "http_server"
+func snake(s string) string {
+	runes := []rune(s)
+	var buf bytes.Buffer
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				buf.WriteRune('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// plural returns the english plural of a noun: "book" -> "books",
+// "box" -> "boxes", "category" -> "categories"
+func plural(s string) string {
+	lower := strings.ToLower(s)
+	switch {
+	case len(s) == 0:
+		return s
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"),
+		strings.HasSuffix(lower, "z"), strings.HasSuffix(lower, "ch"),
+		strings.HasSuffix(lower, "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
@@ -0,0 +1,628 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package engine
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/parser"
+	"github.com/rmescandon/cruder/testdata"
+
+	check "gopkg.in/check.v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type TemplateSuite struct{}
+
+var _ = check.Suite(&TemplateSuite{})
+
+func (s *TemplateSuite) SetUpTest(c *check.C) {
+	config.Config.ProjectURL = "server.dom/namespace/project"
+	config.Config.APIVersion = "v1.0"
+}
+
+func (s *TemplateSuite) TestExecute(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := execute(h, "test",
+		`{{.Name}} {{.Identifier}} {{.ProjectURL}} {{.APIVersion}}:{{range .Fields}} {{snake .Name}}={{sqlType .Type}}{{end}}`)
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Equals,
+		"MyType myType server.dom/namespace/project v1.0: id=integer name=varchar description=varchar the_bool_thing=boolean the_float_thing=real")
+}
+
+func (s *TemplateSuite) TestExecute_dialect(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Dialect = parser.MySQL
+
+	str, err := execute(h, "test",
+		`{{.Dialect}} {{.Dialect.DriverImport}} {{.Dialect.Placeholder 1}}:{{range .Fields}} {{sqlType .Type}}{{end}}`)
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Equals,
+		"mysql github.com/go-sql-driver/mysql ?: integer varchar(255) varchar(255) boolean float")
+}
+
+func (s *TemplateSuite) TestMerge_postgresDatastore(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Dialect = parser.Postgres
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*values \(\$1, \$2, \$3, \$4\) returning id".*`)
+	c.Assert(str, check.Matches, `(?s).*err := db.QueryRowContext\(ctx, createMyTypeSQL, .*\).Scan\(&id\).*`)
+	c.Assert(strings.Contains(str, "LastInsertId"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_listDatastore(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*var MyTypeListFields = map\[string\]ListField\{
+	"ID": +\{Column: "id", Kind: "int"\},
+	"Name": +\{Column: "name", Kind: "string"\},.*`)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) ListMyTypes\(ctx context.Context, options ListOptions\) \(\[\]MyType, int, error\).*`)
+}
+
+func (s *TemplateSuite) TestMerge_validateDatastore(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields[1].Tags.Required = true
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func \(myType MyType\) Validate\(\) error \{
+	var fieldErrors ValidationError
+	if myType.Name == "" \{
+		fieldErrors = append\(fieldErrors, FieldError\{Field: "Name", Message: "is required"\}\)
+	\}
+
+	if len\(fieldErrors\) > 0 \{.*`)
+}
+
+func (s *TemplateSuite) TestMerge_handlerValidation(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, `if err := myType.Validate(); err != nil {
+		replyWithValidationError(err, w)
+		return
+	}`), check.Equals, 2)
+}
+
+func (s *TemplateSuite) TestMerge_nestedRoutes(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields = append(h.Fields, parser.TypeField{
+		Name: "OwnerID",
+		Type: "int",
+		Ref:  &parser.Reference{Type: "Owner", Table: "owner", Column: "id"},
+	})
+
+	str, err := merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches,
+		`(?s).*router.Handle\(composePath\("owner/\{ownerid:\[0-9\]\+\}/mytypes"\), http.HandlerFunc\(handler.ListOwnerMyTypes\)\).Methods\("GET"\).*`)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func ListOwnerMyTypes\(w http.ResponseWriter, r \*http.Request\) \{
+	vars := mux.Vars\(r\)
+	ownerID, err := strconv.Atoi\(vars\["ownerid"\]\).*`)
+	c.Assert(str, check.Matches,
+		`(?s).*listMyTypes\(w, r, &datastore.Filter\{Column: "ownerid", Operator: datastore.Equal, Value: ownerID\}\).*`)
+}
+
+func (s *TemplateSuite) TestMerge_mysqlQuery(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Dialect = parser.MySQL
+
+	str, err := merge(h, "../testdata/templates/query.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func placeholder\(n int\) string \{\n\treturn "\?"\n\}.*`)
+	c.Assert(str, check.Matches, `(?s).*query \+= " limit 18446744073709551615".*`)
+	c.Assert(str, check.Matches, `(?s).*fmt.Sprintf\("instr\(cast\(%v as binary\), %v\) > 0", column, placeholder\(len\(args\)\)\).*`)
+}
+
+func (s *TemplateSuite) TestMerge_search(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields[2].Tags.Search = true
+	h.Fields[2].Tags.SearchNoCase = true
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*var MyTypeSearchColumns = \[\]SearchColumn\{
+	\{Column: "description", NoCase: true\},
+\}.*`)
+	c.Assert(strings.Contains(str, "FindMyType"), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*parseListParams\(r.URL.Query\(\), datastore.MyTypeListFields, datastore.MyTypeSearchColumns, "ID"\).*`)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\{"search", "GET", listURL \+ "\?q=" \+ url.QueryEscape\(created.Description\), .*`)
+
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func TestMyTypeSearch.*Search: &Search\{Text: "XYZZY", Columns: MyTypeSearchColumns\}.*`)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*- name: q.*description: Keeps the mytypes containing the text in Description.*`)
+}
+
+func (s *TemplateSuite) TestMerge_noSearch(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields[1].Type = "int"
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*var MyTypeSearchColumns = \[\]SearchColumn\{\}.*`)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\{"search not supported", "GET", listURL \+ "\?q=xyzzy", "", nil, http.StatusBadRequest, nil\}.*`)
+	c.Assert(strings.Contains(str, `"net/url"`), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_datastoreTest(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*err := OpenSysDatabase\("sqlite3", ":memory:"\).*`)
+	c.Assert(str, check.Matches, `(?s).*return MyType\{
+		Name: +fmt.Sprintf\("Name %d", n\),
+		Description: +fmt.Sprintf\("Description %d", n\),
+		TheBoolThing: +n%2 == 0,
+		TheFloatThing: +float32\(n\) \+ 0.5,
+	\}.*`)
+	c.Assert(strings.Contains(str, `"os"`), check.Equals, false)
+	c.Assert(strings.Contains(str, "Db.SetMaxOpenConns(1)"), check.Equals, true)
+}
+
+func (s *TemplateSuite) TestMerge_postgresHandlerTest(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Dialect = parser.Postgres
+
+	str, err := merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*package handler_test.*`)
+	c.Assert(str, check.Matches, `(?s).*err := datastore.OpenSysDatabase\("postgres", dataSource\).*`)
+	c.Assert(str, check.Matches, `(?s).*listURL := "/v1.0/mytype".*`)
+	c.Assert(strings.Contains(str, "SetMaxOpenConns"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_openAPI(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+
+	spec := map[string]interface{}{}
+	c.Assert(yaml.Unmarshal([]byte(str), &spec), check.IsNil)
+	c.Assert(spec["paths"], check.HasLen, 3)
+	c.Assert(str, check.Matches, `(?s).*  /v1\.0/mytype/\{id\}:\n    parameters:.*`)
+	c.Assert(str, check.Matches, `(?s).*        "TheBoolThing": \{type: boolean\}\n.*`)
+
+	// operations validating the fields reply invalid values with 422
+	type operation struct {
+		Responses map[string]interface{}
+	}
+	doc := struct {
+		Paths map[string]struct{ Post, Put, Patch *operation }
+	}{}
+	c.Assert(yaml.Unmarshal([]byte(str), &doc), check.IsNil)
+	for _, op := range []*operation{doc.Paths["/v1.0/mytype"].Post, doc.Paths["/v1.0/mytype/{id}"].Put,
+		doc.Paths["/v1.0/mytype/{id}"].Patch, doc.Paths["/v1.0/mytype/batch"].Post} {
+		c.Assert(op, check.NotNil)
+		c.Assert(op.Responses["422"], check.NotNil)
+	}
+}
+
+func (s *TemplateSuite) TestExecute_invalidTemplate(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	_, err = execute(h, "test", "{{.Name")
+	c.Assert(err, check.ErrorMatches, "Error parsing template test: .*")
+}
+
+func (s *TemplateSuite) TestExecute_unknownField(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	_, err = execute(h, "test", "{{.Whatever}}")
+	c.Assert(err, check.ErrorMatches, "Error executing template test: .*")
+}
+
+func (s *TemplateSuite) TestMerge_legacyTemplate(c *check.C) {
+	f, err := ioutil.TempFile("", "")
+	c.Assert(err, check.IsNil)
+	defer f.Close()
+
+	_, err = f.WriteString("_#TYPE#_ _#ID.FIELD.COLUMN#_ _#PROJECT#_")
+	c.Assert(err, check.IsNil)
+
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, f.Name())
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Equals, "MyType id server.dom/namespace/project")
+}
+
+func (s *TemplateSuite) TestIsLegacyTemplate(c *check.C) {
+	c.Assert(isLegacyTemplate("func Get_#TYPE#_()"), check.Equals, true)
+	c.Assert(isLegacyTemplate("func Get{{.Name}}()"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestLowerCamel(c *check.C) {
+	c.Assert(lowerCamel("MyType"), check.Equals, "myType")
+	c.Assert(lowerCamel("ID"), check.Equals, "id")
+	c.Assert(lowerCamel("HTTPServer"), check.Equals, "httpServer")
+	c.Assert(lowerCamel("myType"), check.Equals, "myType")
+	c.Assert(lowerCamel(""), check.Equals, "")
+}
+
+func (s *TemplateSuite) TestSnake(c *check.C) {
+	c.Assert(snake("MyType"), check.Equals, "my_type")
+	c.Assert(snake("ID"), check.Equals, "id")
+	c.Assert(snake("AuthorID"), check.Equals, "author_id")
+	c.Assert(snake("HTTPServer"), check.Equals, "http_server")
+	c.Assert(snake("Field1Name"), check.Equals, "field1_name")
+	c.Assert(snake(""), check.Equals, "")
+}
+
+func (s *TemplateSuite) TestPlural(c *check.C) {
+	c.Assert(plural("Book"), check.Equals, "Books")
+	c.Assert(plural("Box"), check.Equals, "Boxes")
+	c.Assert(plural("Address"), check.Equals, "Addresses")
+	c.Assert(plural("Category"), check.Equals, "Categories")
+	c.Assert(plural("Key"), check.Equals, "Keys")
+	c.Assert(plural(""), check.Equals, "")
+}
+
+func (s *TemplateSuite) TestMerge_readOnly(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Operations = []string{"list", "get"}
+
+	str, err := merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*handler.ListMyTypes.*handler.GetMyType.*`)
+	c.Assert(str, check.Not(check.Matches), `(?s).*handler.(Create|Update|Patch|Delete)MyType.*`)
+
+	str, err = merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*ListMyTypes\(ctx context.Context, options ListOptions\).*GetMyType\(ctx context.Context, id int\).*`)
+	c.Assert(str, check.Not(check.Matches), `(?s).*(Create|Update|Patch|Delete)MyType.*`)
+
+	str, err = merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) ListMyTypes.*func \(db \*DB\) GetMyType.*`)
+	c.Assert(str, check.Not(check.Matches), `(?s).*(create|update|delete)MyTypeSQL.*`)
+	c.Assert(str, check.Not(check.Matches), `(?s).*func \(db \*DB\) (Create|Update|Patch|Delete)MyType.*`)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func ListMyTypes.*func GetMyType.*`)
+	c.Assert(str, check.Not(check.Matches), `(?s).*func (Create|Update|Patch|Delete)MyType.*`)
+	c.Assert(strings.Contains(str, `"fmt"`), check.Equals, false)
+	c.Assert(strings.Contains(str, `"io"`), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*inserted := insertMyType\(t, sampleMyType\(1\)\)\n\tid := inserted.ID\n.*`)
+	c.Assert(str, check.Matches, `(?s).*\{"create not supported", "POST", listURL, "", sampleMyType\(1\), http.StatusMethodNotAllowed, nil\}.*`)
+	c.Assert(str, check.Matches, `(?s).*\{"delete not supported", "DELETE", itemURL, "", nil, http.StatusMethodNotAllowed, nil\}.*`)
+	c.Assert(strings.Contains(str, `"path"`), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*query := "insert into mytype \(.*`)
+	c.Assert(str, check.Matches, `(?s).*myType, _, err := Db.GetMyType\(context.Background\(\), id\).*`)
+	c.Assert(strings.Contains(str, "Db.DeleteMyType"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_writeOnly(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Operations = []string{"update", "delete"}
+
+	str, err := merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\{"list not supported", "GET", listURL, "", nil, http.StatusNotFound, nil\}.*`)
+	c.Assert(str, check.Matches, `(?s).*\{"get not supported", "GET", itemURL, "", nil, http.StatusMethodNotAllowed, nil\}.*`)
+	c.Assert(strings.Contains(str, "created :="), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*myType, _, err := Db.rowToMyType\(Db.QueryRow\(query, id\)\).*`)
+	c.Assert(strings.Contains(str, "func TestMyTypeList"), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Not(check.Matches), `(?s).*/v1.0/mytype:.*`)
+	c.Assert(str, check.Matches, `(?s).*/v1.0/mytype/\{id\}:.*put:.*delete:.*`)
+}
+
+func (s *TemplateSuite) TestMerge_patch(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Operations = []string{"patch"}
+
+	str, err := merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*http.HandlerFunc\(handler.PatchMyType\)\).Methods\("PATCH"\).*`)
+
+	str, err = merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*PatchMyType\(ctx context.Context, id int, version int, patch func\(\*MyType\) \(\[\]string, error\)\) \(MyType, int, error\).*`)
+
+	str, err = merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*const getMyTypeSQL.*var MyTypeColumns = map\[string\]string\{.*func \(db \*DB\) PatchMyType.*`)
+	c.Assert(strings.Contains(str, `"id": "id"`), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func PatchMyType.*applyMergePatch\(\*current, patch, datastore.MyTypeColumns, &patched\).*`)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\{"patch", "PATCH", itemURL, .*, http.StatusOK, &patched\}.*`)
+	c.Assert(str, check.Matches, `(?s).*\{"get not supported", "GET", itemURL, "", nil, http.StatusMethodNotAllowed, nil\}.*`)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*/v1.0/mytype/\{id\}:.*patch:.*application/merge-patch\+json:.*`)
+}
+
+func (s *TemplateSuite) TestMerge_uuidKey(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields[0].Type = "string"
+	h.Fields[0].Tags.ID = true
+	h.Fields[0].Tags.UUID = true
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*const createMyTypeSQL = "insert into mytype \(id, name, .*\) values \(\$1, \$2, .*\)".*`)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) CreateMyType\(ctx context.Context, myType MyType\) \(string, error\) \{\n\tid, err := newUUID\(\).*`)
+
+	str, err = merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, `composePath("mytype/{id:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}")`), check.Equals, true)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*id := path.Base\(w.Header\(\).Get\("Location"\)\).*`)
+	c.Assert(strings.Contains(str, `"strconv"`), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*- name: id\n.*      schema: \{type: string, format: uuid\}\n.*`)
+}
+
+func (s *TemplateSuite) TestMerge_compositeKey(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Fields[0].Tags.ID = true
+	h.Fields[1].Tags.ID = true
+
+	str, err := merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*GetMyType\(ctx context.Context, id int, name string\) \(MyType, int, error\).*CreateMyType\(ctx context.Context, myType MyType\) \(int, string, error\).*`)
+
+	str, err = merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*const getMyTypeSQL = "select id, name, .* from mytype where id=\$1 and name=\$2".*`)
+	c.Assert(str, check.Matches, `(?s).*return myType.ID, myType.Name, nil.*`)
+
+	str, err = merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, `composePath("mytype/{id:[0-9]+}/{name:[a-zA-Z0-9_.-]+}")`), check.Equals, true)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*id, name, err := parseMyTypeKey\(mux.Vars\(r\)\).*`)
+	c.Assert(str, check.Matches, `(?s).*parseListParams\(r.URL.Query\(\), datastore.MyTypeListFields, datastore.MyTypeSearchColumns, ""\).*`)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*id, name := sampleMyType\(1\).ID, sampleMyType\(1\).Name.*`)
+
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, `{"cursor", `), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_audit(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+	h.Audit = true
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*const getMyTypeSQL = "select .* from mytype where id=\$1 and deleted_at is null".*`)
+	c.Assert(str, check.Matches, `(?s).*const createMyTypeSQL = "insert into mytype \(name, .*, created_at, updated_at\) values \(\$1, .*, current_timestamp, current_timestamp\)".*`)
+	c.Assert(str, check.Matches, `(?s).*const deleteMyTypeSQL = "update mytype set deleted_at=current_timestamp, row_version=row_version\+1 where id=\$1 and deleted_at is null".*`)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) ListMyTypes\(ctx context.Context, options ListOptions\) \(\[\]MyType, int, error\) \{\n\toptions.deletedColumn = "deleted_at".*`)
+
+	str, err = merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*GetMyType\(ctx context.Context, id int, includeDeleted bool\) \(MyType, int, error\).*`)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*includeDeleted, err := parseIncludeDeleted\(r.URL.Query\(\)\).*Store.GetMyType\(r.Context\(\), id, includeDeleted\).*`)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "- name: include_deleted"), check.Equals, 2)
+
+	h.Audit = false
+	str, err = merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, "deleted_at"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_version(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*const getMyTypeSQL = "select id, .*, row_version from mytype where id=\$1".*`)
+	c.Assert(str, check.Matches, `(?s).*const updateMyTypeSQL = "update mytype set .*, row_version=row_version\+1 where id=\$5".*`)
+	c.Assert(str, check.Matches, `(?s).*query \+= " and row_version=" \+ placeholder\(len\(args\)\).*return checkVersion\(result\).*`)
+
+	str, err = merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*UpdateMyType\(ctx context.Context, id int, myType MyType, version int\) error.*DeleteMyType\(ctx context.Context, id int, version int\) error.*`)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*w.Header\(\).Set\("ETag", etag\(version\)\).*`)
+	c.Assert(strings.Count(str, "version, ok := parseIfMatch(r)"), check.Equals, 3)
+	c.Assert(strings.Count(str, "if err == datastore.ErrVersionMismatch {"), check.Equals, 3)
+
+	str, err = merge(h, "../testdata/templates/openapi.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "- name: If-Match"), check.Equals, 3)
+	c.Assert(strings.Count(str, `"412":`), check.Equals, 4)
+
+	str, err = merge(h, "../testdata/templates/handlertest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, "(?s).*\\{\"update\", \"PUT\", itemURL, `\"1\"`, .*\\{\"patch\", \"PATCH\", itemURL, `\"2\"`, .*\\{\"delete\", \"DELETE\", itemURL, `\"3\"`, .*")
+}
+
+func (s *TemplateSuite) TestMerge_batch(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) BatchMyTypes\(ctx context.Context, operations \[\]MyTypeOperation\) \(\[\]MyType, error\).*`)
+	c.Assert(str, check.Matches, `(?s).*err := db.WithTx\(ctx, func\(tx Datastore\) error \{.*case BatchCreate:\n\t+myType.ID, err = tx.CreateMyType\(ctx, myType\).*case BatchDelete:\n\t+err = tx.DeleteMyType\(ctx, myType.ID, operation.Version\).*`)
+
+	str, err = merge(h, "../testdata/templates/router.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, `router.Handle(composePath("mytype/batch"), http.HandlerFunc(handler.BatchMyTypes)).Methods("POST")`), check.Equals, true)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*case datastore.BatchUpdate, datastore.BatchDelete:\n\t+version, ok := parseETag\(op.ETag\).*`)
+	c.Assert(str, check.Matches, `(?s).*"Action must be create, update, delete".*`)
+
+	h.Operations = []string{"list", "delete", "batch"}
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*_, err := Store.BatchMyTypes\(r.Context\(\), operations\).*`)
+	c.Assert(strings.Contains(str, "datastore.BatchCreate"), check.Equals, false)
+
+	h.Operations = []string{"list", "delete"}
+	str, err = merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, "BatchMyTypes"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_withTx(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*type Datastore interface \{\n\tApplyMigrations\(ctx context.Context\) error\n\tWithTx\(ctx context.Context, apply func\(Datastore\) error\) error\n.*`)
+	c.Assert(str, check.Matches, `(?s).*if err := apply\(&DB\{DB: db.DB, tx: tx\}\); err != nil \{\n\t\ttx.Rollback\(\).*`)
+	c.Assert(str, check.Matches, `(?s).*func \(db \*DB\) ExecContext\(ctx context.Context, query string, args \.\.\.interface\{\}\) \(sql.Result, error\) \{\n\treturn db.conn\(\).ExecContext\(ctx, query, args\.\.\.\)\n\}.*`)
+
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func TestMyTypeWithTx\(t \*testing.T\) \{.*`)
+
+	h.Operations = []string{"list", "get"}
+	str, err = merge(h, "../testdata/templates/datastoretest.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, "WithTx"), check.Equals, false)
+}
+
+func (s *TemplateSuite) TestMerge_context(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/db.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\tListMyTypes\(ctx context.Context, options ListOptions\).*\tDeleteMyType\(ctx context.Context, id int, version int\) error\n.*`)
+
+	str, err = merge(h, "../testdata/templates/datastore.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*result, err := db.ExecContext\(ctx, query, args\.\.\.\).*`)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*err = Store.UpdateMyType\(r.Context\(\), id, myType, version\).*`)
+
+	str, err = merge(h, "../testdata/templates/service.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, "(?s).*QueryTimeout string `yaml:\"query_timeout\"`.*")
+	c.Assert(str, check.Matches, `(?s).*router := withTimeout\(Router\(\), timeout\).*`)
+}
+
+func (s *TemplateSuite) TestMerge_mock(c *check.C) {
+	h, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	str, err := merge(h, "../testdata/templates/mock.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*type Mock struct \{.*\tGetMyTypeFunc func\(ctx context.Context, id int\) \(MyType, int, error\)\n.*`)
+	c.Assert(str, check.Matches, `(?s).*func \(m \*Mock\) DeleteMyType\(ctx context.Context, id int, version int\) error \{.*`)
+
+	h.Operations = []string{"list", "get"}
+	str, err = merge(h, "../testdata/templates/mock.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*func \(m \*Mock\) ListMyTypes\(.*`)
+	c.Assert(strings.Contains(str, "DeleteMyType"), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/handler.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, "datastore.Db"), check.Equals, false)
+
+	str, err = merge(h, "../testdata/templates/service.template")
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*handler.Store = datastore.Db\n.*`)
+}
@@ -22,6 +22,7 @@ package errs
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Errors
@@ -29,6 +30,7 @@ var (
 	ErrNoMakerRegistered = errors.New("No maker has been registered")
 	ErrNoContent         = errors.New("No content")
 	ErrNilObject         = errors.New("Nil object")
+	ErrChangesPending    = errors.New("There are changes pending to be written")
 )
 
 // ErrOutputExists error struct for an existing output file
@@ -36,6 +38,18 @@ type ErrOutputExists struct {
 	Path string
 }
 
+// ErrStaleOutput error struct for an existing output file, not generated again,
+// using names that are not generated anymore
+type ErrStaleOutput struct {
+	Path  string
+	Names []string
+}
+
+// ErrMakersFailed error struct for the makers not able to generate their output
+type ErrMakersFailed struct {
+	Errors []error
+}
+
 // ErrNotFound error struct for a not existing thing
 type ErrNotFound struct {
 	What string
@@ -56,6 +70,16 @@ func NewErrOutputExists(output string) ErrOutputExists {
 	return ErrOutputExists{Path: output}
 }
 
+// NewErrStaleOutput returns a new ErrStaleOutput struct
+func NewErrStaleOutput(output string, names []string) ErrStaleOutput {
+	return ErrStaleOutput{Path: output, Names: names}
+}
+
+// NewErrMakersFailed returns a new ErrMakersFailed struct
+func NewErrMakersFailed(errors []error) ErrMakersFailed {
+	return ErrMakersFailed{Errors: errors}
+}
+
 // NewErrNotFound returns a new ErrNotFound
 func NewErrNotFound(what string) ErrNotFound {
 	return ErrNotFound{What: what}
@@ -76,6 +100,21 @@ func (e ErrOutputExists) Error() string {
 	return fmt.Sprintf("File %v already exists. Skip writing", e.Path)
 }
 
+// Error returns the error string
+func (e ErrStaleOutput) Error() string {
+	return fmt.Sprintf("File %v uses %v, not generated anymore for the operations its type supports. Update or remove it",
+		e.Path, strings.Join(e.Names, ", "))
+}
+
+// Error returns the error string
+func (e ErrMakersFailed) Error() string {
+	messages := []string{}
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%v makers failed:\n%v", len(e.Errors), strings.Join(messages, "\n"))
+}
+
 // Error returns the error string
 func (e ErrNotFound) Error() string {
 	return fmt.Sprintf("%v not found", e.What)
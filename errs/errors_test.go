@@ -36,6 +36,16 @@ func (s *ErrorSuite) TestErrOutputExists(c *check.C) {
 	c.Assert(err.Error(), check.Equals, "File /any/random/path already exists. Skip writing")
 }
 
+func (s *ErrorSuite) TestErrStaleOutput(c *check.C) {
+	err := NewErrStaleOutput("/any/random/path", []string{"CreateBook", "BookOperation"})
+	c.Assert(err.Error(), check.Equals, "File /any/random/path uses CreateBook, BookOperation, not generated anymore for the operations its type supports. Update or remove it")
+}
+
+func (s *ErrorSuite) TestErrMakersFailed(c *check.C) {
+	err := NewErrMakersFailed([]error{NewErrNotFound("db"), ErrNoContent})
+	c.Assert(err.Error(), check.Equals, "2 makers failed:\ndb not found\nNo content")
+}
+
 func (s *ErrorSuite) TestErrNotFound(c *check.C) {
 	err := NewErrNotFound("Whatever thing")
 	c.Assert(err.Error(), check.Equals, "Whatever thing not found")
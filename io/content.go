@@ -21,11 +21,14 @@ package io
 
 import (
 	"go/ast"
+	"go/token"
 )
 
-// Content payload in two formats, byte arrays or syntax tree
+// Content payload in two formats, byte arrays or syntax tree. Fset holds
+// the positions of the syntax tree nodes and its comments
 type Content struct {
-	Ast *ast.File
+	Ast  *ast.File
+	Fset *token.FileSet
 }
 
 // NewContent returns a pointer to a content struct from a string payload
@@ -35,20 +38,39 @@ func NewContent(str string) (*Content, error) {
 		return nil, err
 	}
 
-	return &Content{ast}, nil
+	return &Content{Ast: ast, Fset: FileSet}, nil
 }
 
 // Bytes returns the content as a byte array
 func (c *Content) Bytes() ([]byte, error) {
-	return ASTToByteArray(c.Ast)
+	b, err := astToBuffer(c.fileSet(), c.Ast)
+	return b.Bytes(), err
 }
 
 // String returns the content as string
 func (c *Content) String() (string, error) {
-	return ASTToString(c.Ast)
+	b, err := astToBuffer(c.fileSet(), c.Ast)
+	return b.String(), err
 }
 
 // Trace dumps content
 func (c *Content) Trace() error {
-	return TraceAST(c.Ast)
+	return ast.Print(c.fileSet(), c.Ast)
+}
+
+// ToFile writes the content to a file
+func (c *Content) ToFile(file string) error {
+	b, err := c.Bytes()
+	if err != nil {
+		return err
+	}
+	return ByteArrayToFile(b, file)
+}
+
+// fileSet returns content file set, or the shared one if not set
+func (c *Content) fileSet() *token.FileSet {
+	if c.Fset == nil {
+		return FileSet
+	}
+	return c.Fset
 }
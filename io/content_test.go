@@ -76,6 +76,7 @@ const (
 import (
 	"database/sql"
 	"fmt"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -88,20 +89,28 @@ type Datastore interface {
 	UpdateMyType(id int, myType MyType)
 	DeleteMyType(id int) error
 }
-type DB struct{ *sql.DB }
+
+type DB struct {
+	*sql.DB
+}
 
 var Db *DB
 
 func OpenSysDatabase(driver, dataSource string) error {
+	// Open the database connection
 	db, err := sql.Open(driver, dataSource)
 	if err != nil {
 		return fmt.Errorf("Error opening the database: %v\n", err)
 	}
+
+	// Check that we have a valid database connection
 	err = db.Ping()
 	if err != nil {
 		return fmt.Errorf("Error accessing the database: %v\n", err)
 	}
+
 	Db = &DB{db}
+
 	return nil
 }
 `
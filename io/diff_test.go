@@ -0,0 +1,78 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package io
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = check.Suite(&DiffSuite{})
+
+func (s *DiffSuite) TestUnifiedDiff_equal(c *check.C) {
+	c.Assert(UnifiedDiff("a", "b", "one\ntwo\n", "one\ntwo\n"), check.Equals, "")
+}
+
+func (s *DiffSuite) TestUnifiedDiff_newFile(c *check.C) {
+	c.Assert(UnifiedDiff("/dev/null", "b", "", "one\ntwo\n"), check.Equals,
+		"--- /dev/null\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n")
+}
+
+func (s *DiffSuite) TestUnifiedDiff_change(c *check.C) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	updated := "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n11\n12\n13\n15\n16\n"
+	c.Assert(UnifiedDiff("a", "b", old, updated), check.Equals, `--- a
++++ b
+@@ -3,7 +3,7 @@
+ 3
+ 4
+ 5
+-6
++six
+ 7
+ 8
+ 9
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++16
+`)
+}
+
+func (s *DiffSuite) TestUnifiedDiff_closeChangesShareHunk(c *check.C) {
+	old := "1\n2\n3\n4\n5\n6\n"
+	updated := "one\n2\n3\n4\n5\nsix\n"
+	c.Assert(UnifiedDiff("a", "b", old, updated), check.Equals, `--- a
++++ b
+@@ -1,6 +1,6 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+-6
++six
+`)
+}
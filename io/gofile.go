@@ -20,7 +20,10 @@
 package io
 
 import (
+	"fmt"
+	"go/build"
 	"os"
+	"path/filepath"
 
 	"github.com/rmescandon/cruder/errs"
 )
@@ -50,6 +53,61 @@ func NewGoFile(filepath string) (*GoFile, error) {
 
 	return &GoFile{
 		Path:    filepath,
-		Content: Content{Ast: ast},
+		Content: Content{Ast: ast, Fset: FileSet},
 	}, nil
 }
+
+// GoFilePaths returns the Go source files referred by paths, which can be
+// files, directories or Go package import paths. Test files and files excluded
+// by build constraints are left out of directories and packages
+func GoFilePaths(paths []string) ([]string, error) {
+	var files []string
+	found := make(map[string]bool)
+	for _, path := range paths {
+		pathFiles, err := goFilePaths(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range pathFiles {
+			if !found[f] {
+				found[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+func goFilePaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var pkg *build.Package
+	if err == nil {
+		pkg, err = build.ImportDir(path, 0)
+	} else {
+		// not in disk, so it has to be a package import path
+		var wd string
+		wd, err = os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		pkg, err = build.Import(path, wd, 0)
+	}
+
+	if _, ok := err.(*build.NoGoError); ok {
+		return nil, fmt.Errorf("No Go source files found in %v", path)
+	}
+	if err != nil {
+		return nil, errs.NewErrNotFound(path)
+	}
+
+	var files []string
+	for _, f := range pkg.GoFiles {
+		files = append(files, filepath.Join(pkg.Dir, f))
+	}
+	return files, nil
+}
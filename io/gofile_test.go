@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package io
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	check "gopkg.in/check.v1"
+)
+
+type GoFileSuite struct {
+	dir string
+}
+
+var _ = check.Suite(&GoFileSuite{})
+
+func (s *GoFileSuite) SetUpTest(c *check.C) {
+	var err error
+	s.dir, err = ioutil.TempDir("", "")
+	c.Assert(err, check.IsNil)
+
+	for name, content := range map[string]string{
+		"book.go":      "package model\n\ntype Book struct{}\n",
+		"author.go":    "package model\n\ntype Author struct{}\n",
+		"book_test.go": "package model\n",
+		"notes.txt":    "not go",
+	} {
+		err = ioutil.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644)
+		c.Assert(err, check.IsNil)
+	}
+}
+
+func (s *GoFileSuite) TearDownTest(c *check.C) {
+	os.RemoveAll(s.dir)
+}
+
+func (s *GoFileSuite) TestGoFilePaths_files(c *check.C) {
+	book := filepath.Join(s.dir, "book.go")
+	author := filepath.Join(s.dir, "author.go")
+
+	files, err := GoFilePaths([]string{book, author, book})
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.DeepEquals, []string{book, author})
+}
+
+func (s *GoFileSuite) TestGoFilePaths_dir(c *check.C) {
+	files, err := GoFilePaths([]string{s.dir})
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.DeepEquals, []string{
+		filepath.Join(s.dir, "author.go"),
+		filepath.Join(s.dir, "book.go"),
+	})
+}
+
+func (s *GoFileSuite) TestGoFilePaths_dirWithoutGoFiles(c *check.C) {
+	empty := filepath.Join(s.dir, "empty")
+	c.Assert(EnsureDir(empty), check.IsNil)
+
+	_, err := GoFilePaths([]string{empty})
+	c.Assert(err, check.ErrorMatches, "No Go source files found in .*empty")
+}
+
+func (s *GoFileSuite) TestGoFilePaths_unknownPackage(c *check.C) {
+	_, err := GoFilePaths([]string{"example.com/not/a/package"})
+	c.Assert(err, check.NotNil)
+}
@@ -23,44 +23,49 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
-	"go/printer"
 	"go/token"
 	"os"
 	"path/filepath"
 )
 
+// FileSet holds the positions of every parsed syntax tree. It is shared so that
+// nodes moved from one tree to another keep valid positions and comments
+var FileSet = token.NewFileSet()
+
 // ByteArrayToAST composes syntax tree from a byte array content
 func ByteArrayToAST(buf []byte) (*ast.File, error) {
-	return parser.ParseFile(token.NewFileSet(), "", buf, 0)
+	return parser.ParseFile(FileSet, "", buf, parser.ParseComments)
 }
 
 // StringToAST composes syntax tree from a string
 func StringToAST(str string) (*ast.File, error) {
-	return parser.ParseFile(token.NewFileSet(), "", str, 0)
+	return parser.ParseFile(FileSet, "", str, parser.ParseComments)
 }
 
 // ASTToString returns a syntax tree as a string
 func ASTToString(ast *ast.File) (string, error) {
-	b, err := astToBuffer(ast)
+	b, err := astToBuffer(FileSet, ast)
 	return b.String(), err
 }
 
 // ASTToByteArray returns a syntax tree as a byte array
 func ASTToByteArray(ast *ast.File) ([]byte, error) {
-	b, err := astToBuffer(ast)
+	b, err := astToBuffer(FileSet, ast)
 	return b.Bytes(), err
 }
 
-func astToBuffer(ast *ast.File) (bytes.Buffer, error) {
+// astToBuffer prints a syntax tree in gofmt style
+func astToBuffer(fset *token.FileSet, ast *ast.File) (bytes.Buffer, error) {
 	var buf bytes.Buffer
-	err := printer.Fprint(&buf, token.NewFileSet(), ast)
+	err := format.Node(&buf, fset, ast)
 	return buf, err
 }
 
 // TraceAST prints out AST file content
 func TraceAST(f *ast.File) error {
-	return ast.Print(token.NewFileSet(), f)
+	return ast.Print(FileSet, f)
 }
 
 // FileToString reads file content and stores it in a string
@@ -93,18 +98,12 @@ func ByteArrayToFile(content []byte, filepath string) error {
 
 // ASTToFile writes a syntax tree to file
 func ASTToFile(ast *ast.File, file string) error {
-	err := EnsureDir(filepath.Dir(file))
+	b, err := astToBuffer(FileSet, ast)
 	if err != nil {
 		return err
 	}
 
-	f, err := os.Create(file)
-	if err != nil {
-		return fmt.Errorf("Could not create %v: %v", file, err)
-	}
-	defer f.Close()
-
-	return printer.Fprint(f, token.NewFileSet(), ast)
+	return writeToFile(b.Bytes(), file)
 }
 
 // writeToFile writes a string content to a file
@@ -42,6 +42,24 @@ type Maker interface {
 	OutputFilepath() string
 }
 
+// FilesMaker is a Maker generating other files besides its Go output file, like
+// sql scripts
+type FilesMaker interface {
+	Maker
+	// Files returns the content of the files generated by last Make call, by path
+	Files() map[string][]byte
+}
+
+// TextMaker is a Maker whose template generates a file that is not Go code,
+// like a yaml one. Engine calls its MakeText method instead of Make
+type TextMaker interface {
+	Maker
+	// MakeText returns the output file content, given the one generated from
+	// the template and the current one, nil if it does not exist yet. A nil
+	// result means there is nothing to write
+	MakeText(generatedOutput []byte, currentOutput []byte) ([]byte, error)
+}
+
 // Base represents common members for any maker
 type Base struct {
 	TypeHolder *parser.TypeHolder
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const batchreplyTestContent = `
+	package handler
+
+	const maxBatchOperations = 1000
+	`
+
+type BatchReplySuite struct {
+	br *BatchReply
+}
+
+var _ = check.Suite(&BatchReplySuite{})
+
+func (s *BatchReplySuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.br = &BatchReply{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *BatchReplySuite) TestID(c *check.C) {
+	c.Assert(s.br.ID(), check.Equals, "batchreply")
+}
+
+func (s *BatchReplySuite) TestOutputPath(c *check.C) {
+	c.Assert(s.br.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "handler", "batch.go"))
+}
+
+func (s *BatchReplySuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(batchreplyTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.br.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *BatchReplySuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(batchreplyTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.br.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
@@ -0,0 +1,196 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+)
+
+// Client generates client/<type>.go output go file, calling the type API operations
+type Client struct {
+	makers.Base
+}
+
+// ID returns 'client' as this maker identifier
+func (cl *Client) ID() string {
+	return "client"
+}
+
+// OutputFilepath returns the path to generated file
+func (cl *Client) OutputFilepath() string {
+	if cl.TypeHolder == nil || len(cl.TypeHolder.Name) == 0 {
+		return ""
+	}
+
+	return filepath.Join(
+		makers.BasePath,
+		cl.ID(),
+		strings.ToLower(cl.TypeHolder.Name)+".go")
+}
+
+// Make generates the result, including the type definition so that client
+// package does not depend on the datastore one
+func (cl *Client) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	if generatedOutput == nil {
+		return nil, errs.ErrNoContent
+	}
+
+	// reparse generated output so that offsets refer to its printed source
+	src, err := generatedOutput.String()
+	if err != nil {
+		return nil, err
+	}
+	generated, err := io.NewContent(src)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := parser.GetFuncDecls(generated.Ast)
+	if len(funcs) == 0 {
+		return nil, errs.NewErrNotFound("First function in generated output")
+	}
+
+	// type definition is inserted as text just before first function and its
+	// doc, as comments are placed by their offsets when nodes are printed
+	pos := funcs[0].Pos()
+	if funcs[0].Doc != nil {
+		pos = funcs[0].Doc.Pos()
+	}
+	offset := io.FileSet.Position(pos).Offset
+
+	decl, err := clientTypeDecl(cl.TypeHolder.Decl)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := io.NewContent(src[:offset] + decl + "\n\n" + src[offset:])
+	if err != nil {
+		return nil, err
+	}
+
+	// packages used by type declaration fields must be imported too
+	for _, imp := range cl.TypeHolder.Imports() {
+		parser.AddImport(output.Ast, imp)
+	}
+
+	return output, nil
+}
+
+// clientTypeDecl returns the source of the type declaration as used by the
+// client, whose fields only keep their json tags, as the rest are about the
+// storage of the type, as cruder directives in its docs are
+func clientTypeDecl(decl *ast.GenDecl) (string, error) {
+	var buf bytes.Buffer
+	err := format.Node(&buf, io.FileSet, decl)
+	if err != nil {
+		return "", err
+	}
+
+	// the printed declaration is reparsed and edited as text, so that the one
+	// of the type holder is not changed and comments keep their places
+	src := "package client\n\n" + buf.String()
+	content, err := io.NewContent(src)
+	if err != nil {
+		return "", err
+	}
+
+	type splice struct {
+		start, end int
+		text       string
+	}
+	splices := []splice{}
+	offset := func(pos token.Pos) int {
+		return io.FileSet.Position(pos).Offset
+	}
+
+	stripDirectives := func(doc *ast.CommentGroup) {
+		if doc == nil {
+			return
+		}
+
+		kept := make(map[*ast.Comment]bool)
+		if stripped := parser.StripDirectives(doc); stripped != nil {
+			for _, comment := range stripped.List {
+				kept[comment] = true
+			}
+		}
+		for _, comment := range doc.List {
+			if !kept[comment] {
+				// along with its line break
+				splices = append(splices, splice{offset(comment.Pos()), offset(comment.End()) + 1, ""})
+			}
+		}
+	}
+
+	// nodes are inspected in source order, so splices are sorted by offset
+	ast.Inspect(content.Ast, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.GenDecl:
+			stripDirectives(node.Doc)
+		case *ast.TypeSpec:
+			stripDirectives(node.Doc)
+		case *ast.Field:
+			if node.Tag == nil {
+				break
+			}
+
+			tag, err := strconv.Unquote(node.Tag.Value)
+			if err != nil {
+				break
+			}
+
+			if json, ok := reflect.StructTag(tag).Lookup("json"); ok {
+				splices = append(splices, splice{offset(node.Tag.Pos()), offset(node.Tag.End()),
+					"`json:" + strconv.Quote(json) + "`"})
+			} else {
+				splices = append(splices, splice{offset(node.Type.End()), offset(node.Tag.End()), ""})
+			}
+		}
+		return true
+	})
+
+	// from the last one, so that offsets of the previous ones remain valid
+	for i := len(splices) - 1; i >= 0; i-- {
+		sp := splices[i]
+		src = src[:sp.start] + sp.text + src[sp.end:]
+	}
+
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(formatted), "package client\n")), nil
+}
+
+func init() {
+	makers.Register(&Client{})
+}
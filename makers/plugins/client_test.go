@@ -0,0 +1,210 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const (
+	clientTestContent = `package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// MyTypeClient performs mytype API operations
+type MyTypeClient struct {
+	client *Client
+}
+
+// Delete deletes a mytype
+func (c *MyTypeClient) Delete(ctx context.Context, id int) error {
+	_, err := c.client.do(ctx, http.MethodDelete, "mytype/"+strconv.Itoa(id), nil, nil, nil)
+	return err
+}
+`
+
+	clientBaseTestContent = `package client
+
+// Client calls the REST API of the service
+type Client struct {
+	BaseURL string
+}
+
+// MyTypes returns the client of mytype API operations
+func (c *Client) MyTypes() *MyTypeClient {
+	return &MyTypeClient{client: c}
+}
+`
+
+	clientBaseOtherTestContent = `package client
+
+// Client calls the REST API of the service
+type Client struct {
+	BaseURL string
+}
+
+// MyOtherTypes returns the client of myothertype API operations
+func (c *Client) MyOtherTypes() *MyOtherTypeClient {
+	return &MyOtherTypeClient{client: c}
+}
+`
+)
+
+type ClientSuite struct {
+	cl *Client
+	cb *ClientBase
+}
+
+var _ = check.Suite(&ClientSuite{})
+
+func (s *ClientSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.cl = &Client{makers.Base{TypeHolder: typeHolder}}
+	s.cb = &ClientBase{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *ClientSuite) TestID(c *check.C) {
+	c.Assert(s.cl.ID(), check.Equals, "client")
+	c.Assert(s.cb.ID(), check.Equals, "clientbase")
+}
+
+func (s *ClientSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.cl.OutputFilepath(), check.Equals, filepath.Join(makers.BasePath, "client", "mytype.go"))
+	c.Assert(s.cb.OutputFilepath(), check.Equals, filepath.Join(makers.BasePath, "client", "client.go"))
+}
+
+func (s *ClientSuite) TestOutputPath_nilType(c *check.C) {
+	s.cl.TypeHolder = nil
+	c.Assert(s.cl.OutputFilepath(), check.Equals, "")
+}
+
+func (s *ClientSuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(clientTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cl.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*type MyType struct \{.*\}\n\n// Delete deletes a mytype.*`)
+}
+
+func (s *ClientSuite) TestMake_taggedFields(c *check.C) {
+	source, err := io.NewContent(`
+	package mytype
+
+	// MyType is tagged
+	//
+	//cruder:audit
+	type MyType struct {
+		ID    int    ` + "`cruder:\"id\" json:\"key\"`" + `
+		Name  string ` + "`validate:\"required\" cruder:\"search\"`" + `
+		Notes string ` + "`json:\"-\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	holders, err := parser.ComposeTypeHolders(&io.GoFile{Content: *source})
+	c.Assert(err, check.IsNil)
+	c.Assert(holders, check.HasLen, 1)
+	s.cl.TypeHolder = holders[0]
+
+	generatedOutput, err := io.NewContent(clientTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cl.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, "(?s).*\tID +int +`json:\"key\"`\n\tName +string\n\tNotes +string +`json:\"-\"`\n}.*")
+	c.Assert(str, check.Matches, "(?s).*\n// MyType is tagged\ntype MyType struct \\{.*")
+	c.Assert(strings.Contains(str, "cruder:"), check.Equals, false)
+}
+
+func (s *ClientSuite) TestMake_withoutFunctions(c *check.C) {
+	generatedOutput, err := io.NewContent("package client\n")
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cl.Make(generatedOutput, nil)
+	c.Assert(output, check.IsNil)
+	_, ok := err.(errs.ErrNotFound)
+	c.Assert(ok, check.Equals, true)
+}
+
+func (s *ClientSuite) TestMakeBase(c *check.C) {
+	generatedOutput, err := io.NewContent(clientBaseTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cb.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *ClientSuite) TestMakeBase_existingOutput(c *check.C) {
+	currentOutput, err := io.NewContent(clientBaseTestContent)
+	c.Assert(err, check.IsNil)
+	generatedOutput, err := io.NewContent(clientBaseOtherTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cb.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "type Client struct"), check.Equals, 1)
+	c.Assert(str, check.Matches, `(?s).*func \(c \*Client\) MyTypes\(\) \*MyTypeClient \{.*`)
+	c.Assert(str, check.Matches, `(?s).*\n\n// MyOtherTypes returns the client of myothertype API operations\nfunc \(c \*Client\) MyOtherTypes\(\).*`)
+}
+
+func (s *ClientSuite) TestMakeBase_nothingToAdd(c *check.C) {
+	currentOutput, err := io.NewContent(clientBaseTestContent)
+	c.Assert(err, check.IsNil)
+	generatedOutput, err := io.NewContent(clientBaseTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.cb.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "func (c *Client) MyTypes()"), check.Equals, 1)
+}
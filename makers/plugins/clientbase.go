@@ -0,0 +1,100 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/printer"
+	"go/types"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+)
+
+// ClientBase generates client/client.go output go file, shared by the clients
+// of all types
+type ClientBase struct {
+	makers.Base
+}
+
+// ID returns 'clientbase' as this maker identifier
+func (cb *ClientBase) ID() string {
+	return "clientbase"
+}
+
+// OutputFilepath returns the path to generated file
+func (cb *ClientBase) OutputFilepath() string {
+	return filepath.Join(makers.BasePath, "client/client.go")
+}
+
+// Make adds to current output the generated functions it misses, like the
+// accessor of the client of a new type
+func (cb *ClientBase) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	if generatedOutput == nil {
+		return nil, errs.ErrNoContent
+	}
+
+	if currentOutput == nil {
+		return generatedOutput, nil
+	}
+
+	existing := make(map[string]bool)
+	for _, f := range parser.GetFuncDecls(currentOutput.Ast) {
+		existing[clientFuncKey(f)] = true
+	}
+
+	src, err := currentOutput.String()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range parser.GetFuncDecls(generatedOutput.Ast) {
+		if existing[clientFuncKey(f)] {
+			continue
+		}
+
+		// printed along with its doc comment, which is not moved with the node
+		var buf bytes.Buffer
+		err := format.Node(&buf, io.FileSet, &printer.CommentedNode{Node: f, Comments: generatedOutput.Ast.Comments})
+		if err != nil {
+			return nil, err
+		}
+		src += "\n" + buf.String() + "\n"
+	}
+
+	return io.NewContent(src)
+}
+
+// clientFuncKey returns the name of a function, qualified by its receiver type if it is a method
+func clientFuncKey(f *ast.FuncDecl) string {
+	if f.Recv == nil || len(f.Recv.List) == 0 {
+		return f.Name.Name
+	}
+	return types.ExprString(f.Recv.List[0].Type) + "." + f.Name.Name
+}
+
+func init() {
+	makers.Register(&ClientBase{})
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/rmescandon/cruder/errs"
 	"github.com/rmescandon/cruder/io"
 	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
 )
 
 // Datastore generates datastore/<type>.go output go file
@@ -78,6 +79,11 @@ func (ds *Datastore) Make(generatedOutput *io.Content, currentOutput *io.Content
 		return nil, errs.NewErrNotFound("First function in generated output")
 	}
 
+	// packages used by type declaration fields must be imported too
+	for _, imp := range ds.TypeHolder.Imports() {
+		parser.AddImport(generatedOutput.Ast, imp)
+	}
+
 	return generatedOutput, nil
 }
 
@@ -29,6 +29,7 @@ import (
 	"github.com/rmescandon/cruder/errs"
 	"github.com/rmescandon/cruder/io"
 	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
 	"github.com/rmescandon/cruder/testdata"
 
 	check "gopkg.in/check.v1"
@@ -192,3 +193,50 @@ func (s *DatastoreSuite) TestMake_generatedOutputHasntFunctions(c *check.C) {
 		c.Fail()
 	}
 }
+
+func (s *DatastoreSuite) TestMake_typeImports(c *check.C) {
+	source, err := io.NewContent(`
+	package mytype
+
+	import (
+		"fmt"
+		"time"
+	)
+
+	type Audit struct {
+		Created time.Time
+		Updated time.Time
+	}
+
+	// MyType test type to generate skeletom code
+	type MyType struct {
+		ID   int
+		Name string
+		Audit
+	}
+
+	func (t MyType) String() string {
+		return fmt.Sprint(t.ID)
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	holders, err := parser.ComposeTypeHolders(&io.GoFile{Content: *source})
+	c.Assert(err, check.IsNil)
+	c.Assert(holders, check.HasLen, 1)
+	s.datastore.TypeHolder = holders[0]
+
+	generatedOutput, err := io.NewContent(testContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.datastore.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.NotNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(str, "\"time\""), check.Equals, true)
+	c.Assert(strings.Count(str, "\"fmt\""), check.Equals, 1)
+	c.Assert(str, check.Matches, "(?s).*Created\\s+time.Time.*")
+	c.Assert(strings.Contains(str, "Audit"), check.Equals, false)
+}
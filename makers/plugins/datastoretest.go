@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+)
+
+// DatastoreTest makes the tests of the datastore of a type
+type DatastoreTest struct {
+	makers.Base
+}
+
+// ID returns 'datastoretest' as this maker identifier
+func (dt *DatastoreTest) ID() string {
+	return "datastoretest"
+}
+
+// OutputFilepath returns the path to the generated file
+func (dt *DatastoreTest) OutputFilepath() string {
+	if dt.TypeHolder == nil || len(dt.TypeHolder.Name) == 0 {
+		return ""
+	}
+
+	return filepath.Join(
+		makers.BasePath,
+		"datastore",
+		strings.ToLower(dt.TypeHolder.Name)+"_test.go")
+}
+
+// Make generates the results. Tests are only written once, so they can be
+// extended by hand
+func (dt *DatastoreTest) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	if currentOutput != nil {
+		if stale := dt.TypeHolder.StaleNames(currentOutput.Ast); len(stale) > 0 {
+			return nil, errs.NewErrStaleOutput(dt.OutputFilepath(), stale)
+		}
+		return nil, errs.NewErrOutputExists(dt.OutputFilepath())
+	}
+
+	return generatedOutput, nil
+}
+
+func init() {
+	makers.Register(&DatastoreTest{})
+}
@@ -21,7 +21,9 @@ package main
 
 import (
 	"fmt"
+	"go/ast"
 	"path/filepath"
+	"strings"
 
 	"github.com/rmescandon/cruder/errs"
 	"github.com/rmescandon/cruder/io"
@@ -50,32 +52,136 @@ func (db *Db) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.
 		return nil, errs.ErrNoContent
 	}
 
-	if currentOutput != nil {
-		generatedIface := parser.GetInterface(generatedOutput.Ast, "Datastore")
-		if generatedIface == nil {
-			return nil, errs.NewErrNotFound("Datastore interface in generated output")
+	if currentOutput == nil {
+		return generatedOutput, nil
+	}
+
+	// both outputs are reparsed so that offsets refer to their printed source.
+	// Methods are merged as text, as comments are placed by their offsets when
+	// nodes are printed, and offsets of nodes of other files collide with them
+	generatedSrc, generatedIface, err := dbInterface(generatedOutput)
+	if err != nil {
+		return nil, errs.NewErrNotFound("Datastore interface in generated output")
+	}
+
+	src, currentIface, err := dbInterface(currentOutput)
+	if err != nil {
+		return nil, errs.NewErrNotFound("Datastore interface in current output")
+	}
+
+	// generated methods replace current ones, so that they follow changes of
+	// the type key, or are added if not found. Docs of current methods are kept
+	// unless generated ones have their own
+	methods := make(map[string]string)
+	documented := make(map[string]bool)
+	names := []string{}
+	for _, method := range parser.GetInterfaceMethods(generatedIface) {
+		if method == nil || len(method.Names) < 1 || method.Names[0] == nil {
+			continue
+		}
+
+		start, end := dbMethodOffsets(method)
+		methods[method.Names[0].Name] = generatedSrc[start:end]
+		documented[method.Names[0].Name] = method.Doc != nil
+		names = append(names, method.Names[0].Name)
+	}
+
+	// methods of the operations the type does not support anymore are removed
+	removed := make(map[string]bool)
+	if db.TypeHolder != nil {
+		for _, name := range db.TypeHolder.UnsupportedFuncNames() {
+			removed[name] = true
 		}
 
-		currentIface := parser.GetInterface(currentOutput.Ast, "Datastore")
-		if currentIface == nil {
-			return nil, errs.NewErrNotFound("Datastore interface in current output")
+		// searches moved from Find methods to lists
+		findName := "Find" + db.TypeHolder.Name
+		if !parser.HasMethod(generatedIface, findName) {
+			removed[findName] = true
 		}
+	}
 
-		// Search for generatedIface methods into currentIface and add them if not found
-		for _, method := range parser.GetInterfaceMethods(generatedIface) {
-			if method == nil || len(method.Names) < 1 || method.Names[0] == nil {
-				continue
-			}
+	type splice struct {
+		start, end int
+		text       string
+	}
+	splices := []splice{}
+	for _, method := range parser.GetInterfaceMethods(currentIface) {
+		if method == nil || len(method.Names) < 1 || method.Names[0] == nil {
+			continue
+		}
 
-			if !parser.HasMethod(currentIface, method.Names[0].Name) {
-				parser.AddMethod(currentIface, method)
+		name := method.Names[0].Name
+		start, end := dbMethodOffsets(method)
+		if text, ok := methods[name]; ok {
+			if !documented[name] {
+				start = io.FileSet.Position(method.Pos()).Offset
 			}
+			splices = append(splices, splice{start, end, text})
+			delete(methods, name)
+		} else if removed[name] {
+			// along with the line break and indentation before it
+			start = strings.LastIndex(src[:start], "\n")
+			splices = append(splices, splice{start, end, ""})
+		}
+	}
+
+	// the methods not found are added at the end of the interface
+	closing := io.FileSet.Position(currentIface.Methods.Closing).Offset
+	added := ""
+	for _, name := range names {
+		if text, ok := methods[name]; ok {
+			added += "\t" + text + "\n"
 		}
+	}
+	splices = append(splices, splice{closing, closing, added})
+
+	// from the last one, so that offsets of the previous ones remain valid
+	for i := len(splices) - 1; i >= 0; i-- {
+		sp := splices[i]
+		src = src[:sp.start] + sp.text + src[sp.end:]
+	}
+
+	output, err := io.NewContent(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// packages used by generated methods must be imported too
+	for _, imp := range generatedOutput.Ast.Imports {
+		parser.AddImport(output.Ast, imp)
+	}
 
-		return currentOutput, nil
+	return output, nil
+}
+
+// dbInterface returns the printed source of content and its Datastore
+// interface, as parsed from it
+func dbInterface(content *io.Content) (string, *ast.InterfaceType, error) {
+	src, err := content.String()
+	if err != nil {
+		return "", nil, err
+	}
+
+	reparsed, err := io.NewContent(src)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return generatedOutput, nil
+	iface := parser.GetInterface(reparsed.Ast, "Datastore")
+	if iface == nil {
+		return "", nil, errs.NewErrNotFound("Datastore interface")
+	}
+	return src, iface, nil
+}
+
+// dbMethodOffsets returns the offsets of the start of an interface method,
+// including its doc comment, and of its end
+func dbMethodOffsets(method *ast.Field) (int, int) {
+	pos := method.Pos()
+	if method.Doc != nil {
+		pos = method.Doc.Pos()
+	}
+	return io.FileSet.Position(pos).Offset, io.FileSet.Position(method.End()).Offset
 }
 
 func init() {
@@ -271,6 +271,122 @@ func (s *DbSuite) TestMake_targetTypeExists(c *check.C) {
 	c.Assert(strings.Count(str, "DeleteMyType(id int) error"), check.Equals, 1)
 }
 
+func (s *DbSuite) TestMake_unsupportedOperations(c *check.C) {
+	s.db.TypeHolder.Operations = []string{"list", "get"}
+
+	generatedOutput, err := io.NewContent(strings.NewReplacer(
+		"CreateMyType(myType MyType) (int, error)", "",
+		"UpdateMyType(id int, myType MyType)", "",
+		"DeleteMyType(id int) error", "").Replace(oneTypeTestContent))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(oneTypeTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "CreateMyTypeTable() error"), check.Equals, 1)
+	c.Assert(strings.Count(str, "ListMyTypes() ([]MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Count(str, "GetMyType(id int) (MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Count(str, "FindMyType(name string) (MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Count(str, "CreateMyType(myType MyType) (int, error)"), check.Equals, 0)
+	c.Assert(strings.Count(str, "UpdateMyType(id int, myType MyType)"), check.Equals, 0)
+	c.Assert(strings.Count(str, "DeleteMyType(id int) error"), check.Equals, 0)
+}
+
+func (s *DbSuite) TestMake_obsoleteFind(c *check.C) {
+	generatedOutput, err := io.NewContent(strings.Replace(oneTypeTestContent,
+		"FindMyType(name string) (MyType, error)", "", 1))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(oneTypeTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "GetMyType(id int) (MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Count(str, "FindMyType(name string) (MyType, error)"), check.Equals, 0)
+}
+
+func (s *DbSuite) TestMake_changedKey(c *check.C) {
+	generatedOutput, err := io.NewContent(strings.Replace(oneTypeTestContent,
+		"GetMyType(id int) (MyType, error)", "GetMyType(code string) (MyType, error)", 1))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(oneTypeTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "GetMyType(id int) (MyType, error)"), check.Equals, 0)
+	c.Assert(strings.Count(str, "GetMyType(code string) (MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Index(str, "ListMyTypes()") < strings.Index(str, "GetMyType("), check.Equals, true)
+}
+
+func (s *DbSuite) TestMake_keepDocs(c *check.C) {
+	// generated methods have no docs
+	generatedOutput, err := io.NewContent(strings.Replace(dbTestContent("A"), "// GetA returns a register\n", "", 1))
+	c.Assert(err, check.IsNil)
+
+	current := strings.Replace(dbTestContent("A"), "// GetA returns a register", "// GetA returns a register, by hand", 1)
+	current = strings.Replace(current, "GetA(ctx context.Context, id int)", "GetA(ctx context.Context, id int64)", 1)
+	currentOutput, err := io.NewContent(current)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\t// GetA returns a register, by hand\n\tGetA\(ctx context.Context, id int\) \(A, int, error\)\n.*`)
+
+	// unless generated ones have their own
+	generatedOutput, err = io.NewContent(dbTestContent("A"))
+	c.Assert(err, check.IsNil)
+	currentOutput, err = io.NewContent(current)
+	c.Assert(err, check.IsNil)
+
+	output, err = s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err = output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "by hand"), check.Equals, 0)
+	c.Assert(str, check.Matches, `(?s).*\t// GetA returns a register\n\tGetA\(ctx context.Context, id int\) \(A, int, error\)\n.*`)
+}
+
+func (s *DbSuite) TestMake_missingImports(c *check.C) {
+	generatedOutput, err := io.NewContent(dbTestContent("MyType"))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(oneTypeTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.db.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s)package datastore\n\nimport \(\n.*\t"context"\n.*\)\n.*`)
+	c.Assert(strings.Count(str, `"database/sql"`), check.Equals, 1)
+
+	content, err := io.NewContent(str)
+	c.Assert(err, check.IsNil)
+	c.Assert(content.Ast.Imports, check.HasLen, 4)
+}
+
 func (s *DbSuite) TestMake_nilParams(c *check.C) {
 	output, err := s.db.Make(nil, nil)
 	c.Assert(err, check.NotNil)
@@ -337,3 +453,67 @@ func (s *DbSuite) TestMake_currentOutputHasntDatastoreInterface(c *check.C) {
 		c.Fail()
 	}
 }
+
+// dbTestContent returns a datastore interface with the methods of a type,
+// followed by commented declarations, like those of db template
+func dbTestContent(name string) string {
+	return strings.Replace(`
+	package datastore
+
+	import "context"
+
+	// Datastore interface for different data storages
+	type Datastore interface {
+		ApplyMigrations(ctx context.Context) error
+		ListTs(ctx context.Context, options ListOptions) ([]T, int, error)
+		// GetT returns a register
+		GetT(ctx context.Context, id int) (T, int, error)
+		BatchTs(ctx context.Context, operations []TOperation) (
+			[]T,
+			error,
+		)
+	}
+
+	// DB struct holding database implementation for datastore
+	type DB struct {
+		// tx runs the operations, if set
+		tx interface{}
+	}
+
+	// Db pointer to database hander
+	var Db *DB
+	`, "T", name, -1)
+}
+
+func (s *DbSuite) TestMake_manyTypesKeepComments(c *check.C) {
+	current, err := io.NewContent(dbTestContent("A"))
+	c.Assert(err, check.IsNil)
+
+	names := []string{"Bb", "Ccccccccccc", "Dddddddddddddddddddddd", "E", "Ffffffffffffffffffffffffffffffffffffff"}
+	for _, name := range names {
+		generatedOutput, err := io.NewContent(dbTestContent(name))
+		c.Assert(err, check.IsNil)
+
+		// current output is read from the output file, after generating
+		str, err := current.String()
+		c.Assert(err, check.IsNil)
+		currentOutput, err := io.NewContent(str)
+		c.Assert(err, check.IsNil)
+
+		current, err = s.db.Make(generatedOutput, currentOutput)
+		c.Assert(err, check.IsNil)
+	}
+
+	str, err := current.String()
+	c.Assert(err, check.IsNil)
+
+	iface := str[strings.Index(str, "type Datastore interface {"):strings.Index(str, "\n}\n")]
+	for _, name := range append(names, "A") {
+		c.Assert(strings.Count(iface, "\tBatch"+name+"s("), check.Equals, 1)
+		c.Assert(strings.Count(iface, "\t// Get"+name+" returns a register\n\tGet"+name+"("), check.Equals, 1)
+	}
+	c.Assert(strings.Count(iface, "ApplyMigrations(ctx context.Context) error"), check.Equals, 1)
+	c.Assert(strings.Contains(iface, "DB"), check.Equals, false)
+	c.Assert(strings.Count(str, "// DB struct holding database implementation for datastore\ntype DB struct {\n\t// tx runs the operations, if set\n\ttx interface{}\n}"), check.Equals, 1)
+	c.Assert(strings.Count(str, "// Db pointer to database hander\nvar Db *DB"), check.Equals, 1)
+}
@@ -21,6 +21,7 @@ package main
 
 import (
 	"go/ast"
+	"go/types"
 	"path/filepath"
 
 	"github.com/rmescandon/cruder/errs"
@@ -51,40 +52,156 @@ func (d *DDL) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.
 	}
 
 	if currentOutput != nil {
-		// search create table for target type statement in current output. If not found, add it
-		stmt, err := getUpdateDatabaseTargetStatement(currentOutput.Ast, d.TypeHolder.Name)
+		// search the calls of generated UpdateDatabase function in current output. Add the missing ones
+		generatedStmts, err := getUpdateDatabaseStmts(generatedOutput.Ast)
 		if err != nil {
 			return nil, err
 		}
 
-		if stmt == nil {
-			existingStmts, err := getUpdateDatabaseStmts(currentOutput.Ast)
-			if err != nil {
-				return nil, err
+		if _, err := getUpdateDatabaseStmts(currentOutput.Ast); err != nil {
+			return nil, err
+		}
+
+		// UpdateDatabase of former versions takes no context
+		var changed bool
+		currentOutput, changed, err = followUpdateDatabase(generatedOutput, currentOutput)
+		if err != nil {
+			return nil, err
+		}
+
+		existingStmts, err := getUpdateDatabaseStmts(currentOutput.Ast)
+		if err != nil {
+			return nil, err
+		}
+
+		stmtsToAdd := []ast.Stmt{}
+		for _, stmt := range generatedStmts {
+			method := updateDatabaseCallName(stmt)
+			if len(method) == 0 {
+				continue
 			}
 
-			newStmt, err := getUpdateDatabaseTargetStatement(generatedOutput.Ast, d.TypeHolder.Name)
+			existing, err := getUpdateDatabaseCallStatement(currentOutput.Ast, method)
 			if err != nil {
 				return nil, err
 			}
 
-			// prepend to existing statements and update
-			stmtsToSet := append([]ast.Stmt{newStmt}, existingStmts...)
-			err = setStatements(currentOutput.Ast, stmtsToSet)
-			if err != nil {
-				return nil, err
+			if existing == nil {
+				stmtsToAdd = append(stmtsToAdd, stmt)
 			}
+		}
 
-			return currentOutput, nil
+		// if all the statements exist as generated, existing output is valid
+		if len(stmtsToAdd) == 0 && !changed {
+			return nil, nil
 		}
 
-		// if statement exists, existing output is valid
-		return nil, nil
+		// prepend to existing statements and update
+		err = setStatements(currentOutput.Ast, append(stmtsToAdd, existingStmts...))
+		if err != nil {
+			return nil, err
+		}
+
+		return currentOutput, nil
 	}
 
 	return generatedOutput, nil
 }
 
+// followUpdateDatabase returns current output with the parameters of
+// UpdateDatabase and the arguments of the calls in it as generated ones, along
+// with generated imports, and whether it changed. Outputs are edited as text, as
+// comments are placed by the offsets of nodes, colliding with those of others
+func followUpdateDatabase(generatedOutput, currentOutput *io.Content) (*io.Content, bool, error) {
+	generatedSrc, generated, err := reparseDDL(generatedOutput)
+	if err != nil {
+		return nil, false, err
+	}
+
+	src, current, err := reparseDDL(currentOutput)
+	if err != nil {
+		return nil, false, err
+	}
+
+	generatedFunc := findUpdateDatabaseFunction(generated.Ast)
+	currentFunc := findUpdateDatabaseFunction(current.Ast)
+
+	type splice struct {
+		start, end int
+		text       string
+	}
+	splices := []splice{}
+
+	text := func(src string, node ast.Node) (int, int, string) {
+		start, end := io.FileSet.Position(node.Pos()).Offset, io.FileSet.Position(node.End()).Offset
+		return start, end, src[start:end]
+	}
+
+	if types.ExprString(currentFunc.Type) != types.ExprString(generatedFunc.Type) {
+		start, end, _ := text(src, currentFunc.Type.Params)
+		_, _, params := text(generatedSrc, generatedFunc.Type.Params)
+		splices = append(splices, splice{start, end, params})
+	}
+
+	for _, stmt := range currentFunc.Body.List {
+		call := updateDatabaseCall(stmt)
+		if call == nil {
+			continue
+		}
+
+		for _, generatedStmt := range generatedFunc.Body.List {
+			generatedCall := updateDatabaseCall(generatedStmt)
+			if generatedCall == nil || updateDatabaseCallName(generatedStmt) != updateDatabaseCallName(stmt) {
+				continue
+			}
+
+			if types.ExprString(call) != types.ExprString(generatedCall) {
+				start, end, _ := text(src, call)
+				_, _, generatedText := text(generatedSrc, generatedCall)
+				splices = append(splices, splice{start, end, generatedText})
+			}
+		}
+	}
+
+	if len(splices) == 0 {
+		return currentOutput, false, nil
+	}
+
+	// missing imports are declared after the package clause
+	imported := make(map[string]bool)
+	for _, imp := range current.Ast.Imports {
+		imported[imp.Path.Value] = true
+	}
+	packageEnd := io.FileSet.Position(current.Ast.Name.End()).Offset
+	for _, imp := range generated.Ast.Imports {
+		if !imported[imp.Path.Value] {
+			_, _, spec := text(generatedSrc, imp)
+			splices = append([]splice{{packageEnd, packageEnd, "\n\nimport " + spec}}, splices...)
+		}
+	}
+
+	// from the last one, so that offsets of the previous ones remain valid
+	for i := len(splices) - 1; i >= 0; i-- {
+		sp := splices[i]
+		src = src[:sp.start] + sp.text + src[sp.end:]
+	}
+
+	content, err := io.NewContent(src)
+	return content, true, err
+}
+
+// reparseDDL returns the printed source of content and content parsed from it,
+// so that offsets of its nodes refer to that source
+func reparseDDL(content *io.Content) (string, *io.Content, error) {
+	src, err := content.String()
+	if err != nil {
+		return "", nil, err
+	}
+
+	reparsed, err := io.NewContent(src)
+	return src, reparsed, err
+}
+
 func findUpdateDatabaseFunction(file *ast.File) *ast.FuncDecl {
 	funcs := parser.GetFuncDecls(file)
 	for _, f := range funcs {
@@ -104,35 +221,58 @@ func getUpdateDatabaseStmts(file *ast.File) ([]ast.Stmt, error) {
 }
 
 func getUpdateDatabaseTargetStatement(file *ast.File, typeName string) (ast.Stmt, error) {
+	return getUpdateDatabaseCallStatement(file, "Create"+typeName+"Table")
+}
+
+// getUpdateDatabaseCallStatement returns the statement in UpdateDatabase calling a method
+// like: if err := Db.Method(); err != nil {...}
+func getUpdateDatabaseCallStatement(file *ast.File, method string) (ast.Stmt, error) {
 	r := findUpdateDatabaseFunction(file)
 	if r == nil {
 		return nil, errs.NewErrNotFound("UpdateDatabase function")
 	}
 
 	for _, stmt := range r.Body.List {
-		switch stmt.(type) {
-		case *ast.IfStmt:
-			init := stmt.(*ast.IfStmt).Init
-			switch init.(type) {
-			case *ast.AssignStmt:
-				for _, e := range init.(*ast.AssignStmt).Rhs {
-					switch e.(type) {
-					case *ast.CallExpr:
-						switch e.(*ast.CallExpr).Fun.(type) {
-						case *ast.SelectorExpr:
-							if e.(*ast.CallExpr).Fun.(*ast.SelectorExpr).Sel.Name == "Create"+typeName+"Table" {
-								return stmt, nil
-							}
-						}
-					}
-				}
-			}
+		if updateDatabaseCallName(stmt) == method {
+			return stmt, nil
 		}
 	}
 	// If not found the statement, simply return null, but it is not an error
 	return nil, nil
 }
 
+// updateDatabaseCallName returns the name of the method called in statements like:
+// if err := Db.Method(); err != nil {...}
+func updateDatabaseCallName(stmt ast.Stmt) string {
+	if call := updateDatabaseCall(stmt); call != nil {
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+			return sel.Sel.Name
+		}
+	}
+	return ""
+}
+
+// updateDatabaseCall returns the call in statements like:
+// if err := Db.Method(); err != nil {...}
+func updateDatabaseCall(stmt ast.Stmt) *ast.CallExpr {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok {
+		return nil
+	}
+
+	assign, ok := ifStmt.Init.(*ast.AssignStmt)
+	if !ok {
+		return nil
+	}
+
+	for _, e := range assign.Rhs {
+		if call, ok := e.(*ast.CallExpr); ok {
+			return call
+		}
+	}
+	return nil
+}
+
 func setStatements(file *ast.File, stmts []ast.Stmt) error {
 	r := findUpdateDatabaseFunction(file)
 	if r == nil {
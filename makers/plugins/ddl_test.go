@@ -89,6 +89,33 @@ const (
 	}
 	`
 
+	ddlMigrationsTestContent = `package datastore
+
+	import "context"
+
+	// UpdateDatabase creates or updates tables by applying pending migrations. It is
+	// canceled along with ctx
+	func UpdateDatabase(ctx context.Context) error {
+		if err := Db.ApplyMigrations(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	}
+	`
+
+	ddlMigrationsWithoutContextTestContent = `package datastore
+
+	// UpdateDatabase creates or updates tables by applying pending migrations
+	func UpdateDatabase() error {
+		if err := Db.ApplyMigrations(); err != nil {
+			return err
+		}
+
+		return nil
+	}
+	`
+
 	ddlTestContentWithoutStatements = `package datastore
 
 	// UpdateDatabase creates or updates tables by using DDL
@@ -156,6 +183,59 @@ func (s *DDLSuite) TestMake(c *check.C) {
 	c.Assert(strings.Count(str, "if err := Db.CreateMyOtherTypeTable(); err != nil {"), check.Equals, 1)
 }
 
+func (s *DDLSuite) TestMake_migrations(c *check.C) {
+	generatedOutput, err := io.NewContent(ddlMigrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(ddlTestContentWithSeveralStatements)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.ddl.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.NotNil)
+
+	// migrations are applied before creating tables of previous versions
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "if err := Db.ApplyMigrations(ctx); err != nil {"), check.Equals, 1)
+	c.Assert(strings.Index(str, "ApplyMigrations") < strings.Index(str, "CreateMyTypeTable"), check.Equals, true)
+
+	// and only once
+	generatedOutput, err = io.NewContent(ddlMigrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err = s.ddl.Make(generatedOutput, output)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.IsNil)
+}
+
+func (s *DDLSuite) TestMake_migrationsWithoutContext(c *check.C) {
+	generatedOutput, err := io.NewContent(ddlMigrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(ddlMigrationsWithoutContextTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.ddl.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.NotNil)
+
+	// the function and the call of former versions take the context now
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s)package datastore\n\nimport "context"\n\n`+
+		`// UpdateDatabase creates or updates tables by applying pending migrations\n`+
+		`func UpdateDatabase\(ctx context.Context\) error {\n\tif err := Db.ApplyMigrations\(ctx\); err != nil {\n.*`)
+	c.Assert(strings.Count(str, "ApplyMigrations"), check.Equals, 1)
+
+	generatedOutput, err = io.NewContent(ddlMigrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err = s.ddl.Make(generatedOutput, output)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.IsNil)
+}
+
 func (s *DDLSuite) TestMake_currentOutputWithoutStmts(c *check.C) {
 	generatedOutput, err := io.NewContent(ddlMyTypeTestContent)
 	c.Assert(err, check.IsNil)
@@ -53,6 +53,9 @@ func (h *Handler) OutputFilepath() string {
 // Make generates the results
 func (h *Handler) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
 	if currentOutput != nil {
+		if stale := h.TypeHolder.StaleNames(currentOutput.Ast); len(stale) > 0 {
+			return nil, errs.NewErrStaleOutput(h.OutputFilepath(), stale)
+		}
 		return nil, errs.NewErrOutputExists(h.OutputFilepath())
 	}
 
@@ -166,6 +166,17 @@ func (s *HandlerSuite) TestMake_existingOutput(c *check.C) {
 	}
 }
 
+func (s *HandlerSuite) TestMake_staleOutput(c *check.C) {
+	output, err := io.NewContent(handlerTestContent)
+	c.Assert(err, check.IsNil)
+
+	s.handler.TypeHolder.Operations = []string{"get"}
+	out, err := s.handler.Make(output, output)
+	c.Assert(out, check.IsNil)
+	c.Assert(err, check.DeepEquals, errs.NewErrStaleOutput(s.handler.OutputFilepath(),
+		[]string{"ListMyTypes"}))
+}
+
 func (s *HandlerSuite) TestMake_nilGeneratedOutput(c *check.C) {
 	output, err := s.handler.Make(nil, nil)
 	c.Assert(err, check.IsNil)
@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const handlertestTestContent = `
+	package handler_test
+
+	import "testing"
+
+	func TestMyTypeHandlers(t *testing.T) {}
+	`
+
+type HandlerTestSuite struct {
+	ht *HandlerTest
+}
+
+var _ = check.Suite(&HandlerTestSuite{})
+
+func (s *HandlerTestSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.ht = &HandlerTest{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *HandlerTestSuite) TestID(c *check.C) {
+	c.Assert(s.ht.ID(), check.Equals, "handlertest")
+}
+
+func (s *HandlerTestSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.ht.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "handler", "mytype_test.go"))
+}
+
+func (s *HandlerTestSuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(handlertestTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.ht.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *HandlerTestSuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(handlertestTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.ht.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
+
+func (s *HandlerTestSuite) TestMake_staleOutput(c *check.C) {
+	output, err := io.NewContent(`
+	package handler_test
+
+	import "testing"
+
+	func TestMyTypeHandlersWithMock(t *testing.T) {
+		mock := &datastore.Mock{}
+		mock.DeleteMyTypeFunc = nil
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	s.ht.TypeHolder.Operations = []string{"list", "get"}
+	out, err := s.ht.Make(output, output)
+	c.Assert(out, check.IsNil)
+	c.Assert(err, check.DeepEquals, errs.NewErrStaleOutput(s.ht.OutputFilepath(),
+		[]string{"DeleteMyTypeFunc"}))
+}
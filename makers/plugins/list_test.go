@@ -0,0 +1,88 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const listTestContent = `
+	package handler
+
+	const (
+		defaultListLimit = 100
+		maxListLimit     = 1000
+	)
+	`
+
+type ListSuite struct {
+	l *List
+}
+
+var _ = check.Suite(&ListSuite{})
+
+func (s *ListSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.l = &List{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *ListSuite) TestID(c *check.C) {
+	c.Assert(s.l.ID(), check.Equals, "list")
+}
+
+func (s *ListSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.l.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "handler", s.l.ID()+".go"))
+}
+
+func (s *ListSuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(listTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.l.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *ListSuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(listTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.l.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
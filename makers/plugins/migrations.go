@@ -0,0 +1,520 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+)
+
+const (
+	migrationsFolder   = "datastore/migrations"
+	schemaSnapshotFile = "schema.json"
+	migrationsListMark = "var migrations = []migration{}"
+)
+
+var migrationFileRegexp = regexp.MustCompile(`^([0-9]+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrations keeps numbered up and down sql scripts in datastore/migrations folder,
+// adding a new one when a type table changes, and generates datastore/migrations.go
+// file registering all of them.
+// Last known table columns are stored in a schema snapshot in the same folder
+type Migrations struct {
+	makers.Base
+	// files generated by last Make call
+	files map[string][]byte
+	// files generated along this execution, maybe not written yet in dry run mode
+	pending map[string][]byte
+}
+
+// migrationScript holds the sql scripts of a migration version
+type migrationScript struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// ID returns 'migrations' as this maker identifier
+func (m *Migrations) ID() string {
+	return "migrations"
+}
+
+// OutputFilepath returns the path to generated file
+func (m *Migrations) OutputFilepath() string {
+	return filepath.Join(makers.BasePath, "datastore/migrations.go")
+}
+
+// Files returns the sql scripts and schema snapshot generated by last Make call
+func (m *Migrations) Files() map[string][]byte {
+	return m.files
+}
+
+// Make generates a migration if type table changed, and the result registering all migrations
+func (m *Migrations) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	if generatedOutput == nil {
+		return nil, errs.ErrNoContent
+	}
+
+	m.files = make(map[string][]byte)
+	if m.pending == nil {
+		m.pending = make(map[string][]byte)
+	}
+
+	schema, err := m.loadSchemaSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := m.loadMigrationScripts()
+	if err != nil {
+		return nil, err
+	}
+
+	table := m.TypeHolder.TableName()
+	columns := m.TypeHolder.Columns()
+	previous, exists := schema[table]
+
+	script := &migrationScript{version: lastMigrationVersion(scripts) + 1}
+	if !exists {
+		script.name = "create_" + table
+		script.up, script.down = createTableScripts(table, columns)
+	} else {
+		script.name = "alter_" + table
+		// the schema snapshot is kept as it is if changes cannot be migrated
+		script.up, script.down, err = alterTableScripts(m.TypeHolder.Dialect, table, previous, columns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(script.up) > 0 {
+		base := filepath.Join(m.folder(), fmt.Sprintf("%04d_%v", script.version, script.name))
+		m.addFile(base+".up.sql", []byte(script.up))
+		m.addFile(base+".down.sql", []byte(script.down))
+		scripts = append(scripts, script)
+
+		schema[table] = columns
+		b, err := json.MarshalIndent(schema, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+		m.addFile(filepath.Join(m.folder(), schemaSnapshotFile), append(b, '\n'))
+	}
+
+	generated, err := generatedOutput.String()
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(generated, migrationsListMark) {
+		return nil, errs.NewErrNotFound("migrations list in generated output")
+	}
+
+	return io.NewContent(strings.Replace(generated, migrationsListMark, migrationsList(scripts), 1))
+}
+
+func (m *Migrations) folder() string {
+	return filepath.Join(makers.BasePath, migrationsFolder)
+}
+
+func (m *Migrations) addFile(path string, content []byte) {
+	m.files[path] = content
+	m.pending[path] = content
+}
+
+// readFile returns the content of a file generated along this execution or, if
+// it was not, the content in disk
+func (m *Migrations) readFile(path string) ([]byte, error) {
+	if b, ok := m.pending[path]; ok {
+		return b, nil
+	}
+	return io.FileToByteArray(path)
+}
+
+func (m *Migrations) loadSchemaSnapshot() (map[string][]parser.Column, error) {
+	schema := make(map[string][]parser.Column)
+
+	path := filepath.Join(m.folder(), schemaSnapshotFile)
+	b, err := m.readFile(path)
+	if os.IsNotExist(err) {
+		return schema, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &schema)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading schema snapshot %v: %v", path, err)
+	}
+	return schema, nil
+}
+
+// loadMigrationScripts returns existing migrations sorted by version
+func (m *Migrations) loadMigrationScripts() ([]*migrationScript, error) {
+	paths := make(map[string]bool)
+
+	infos, err := ioutil.ReadDir(m.folder())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, info := range infos {
+		paths[filepath.Join(m.folder(), info.Name())] = true
+	}
+	for path := range m.pending {
+		if filepath.Dir(path) == m.folder() {
+			paths[path] = true
+		}
+	}
+
+	byVersion := make(map[int]*migrationScript)
+	for path := range paths {
+		match := migrationFileRegexp.FindStringSubmatch(filepath.Base(path))
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		script, ok := byVersion[version]
+		if !ok {
+			script = &migrationScript{version: version, name: match[2]}
+			byVersion[version] = script
+		} else if script.name != match[2] {
+			return nil, fmt.Errorf("Found several migrations with version %v", version)
+		}
+
+		b, err := m.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if match[3] == "up" {
+			script.up = string(b)
+		} else {
+			script.down = string(b)
+		}
+	}
+
+	scripts := []*migrationScript{}
+	for _, script := range byVersion {
+		scripts = append(scripts, script)
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].version < scripts[j].version })
+
+	return scripts, nil
+}
+
+func lastMigrationVersion(scripts []*migrationScript) int {
+	if len(scripts) == 0 {
+		return 0
+	}
+	return scripts[len(scripts)-1].version
+}
+
+// createTableScripts returns the scripts creating and dropping a table
+func createTableScripts(table string, columns []parser.Column) (string, string) {
+	definitions := []string{}
+	primaryKey := []string{}
+	for _, column := range columns {
+		definitions = append(definitions, "\t"+column.Definition)
+		if column.PrimaryKey {
+			primaryKey = append(primaryKey, column.Name)
+		}
+	}
+	if len(primaryKey) > 0 {
+		definitions = append(definitions, fmt.Sprintf("\tPRIMARY KEY (%v)", strings.Join(primaryKey, ", ")))
+	}
+	for _, column := range columns {
+		if len(column.References) > 0 {
+			definitions = append(definitions, fmt.Sprintf("\tFOREIGN KEY (%v) REFERENCES %v", column.Name, column.References))
+		}
+	}
+
+	up := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (\n%v\n);\n", table, strings.Join(definitions, ",\n"))
+	down := fmt.Sprintf("DROP TABLE %v;\n", table)
+	return up, down
+}
+
+// alterTableScripts returns the scripts migrating table from previous columns to
+// current ones and back. Both are empty if there are no changes. An error is
+// returned if a changed column cannot be migrated in the dialect
+func alterTableScripts(dialect parser.Dialect, table string, previous, current []parser.Column) (string, string, error) {
+	previousByName := make(map[string]parser.Column)
+	for _, column := range previous {
+		previousByName[column.Name] = column
+	}
+
+	currentByName := make(map[string]parser.Column)
+	for _, column := range current {
+		currentByName[column.Name] = column
+	}
+
+	up := []string{}
+	down := []string{}
+	for _, column := range current {
+		old, ok := previousByName[column.Name]
+		switch {
+		case !ok:
+			stmt, err := addColumnStatement(dialect, table, column)
+			if err != nil {
+				return "", "", err
+			}
+			up = append(up, stmt)
+			down = append(down, fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v;", table, column.Name))
+		case old.Definition != column.Definition || old.References != column.References:
+			// sqlite3 cannot change columns, so the table is rebuilt with all the changes
+			if dialect.String() == parser.SQLite3.String() {
+				if err := checkAddedColumns(dialect, table, previous, current); err != nil {
+					return "", "", err
+				}
+				if err := checkAddedColumns(dialect, table, current, previous); err != nil {
+					return "", "", err
+				}
+				return rebuildTableScript(table, previous, current), rebuildTableScript(table, current, previous), nil
+			}
+
+			stmt, err := modifyColumnStatement(dialect, table, old, column)
+			if err != nil {
+				return "", "", err
+			}
+			up = append(up, stmt)
+
+			stmt, err = modifyColumnStatement(dialect, table, column, old)
+			if err != nil {
+				return "", "", err
+			}
+			down = append(down, stmt)
+		}
+	}
+
+	for _, column := range previous {
+		if _, ok := currentByName[column.Name]; !ok {
+			stmt, err := addColumnStatement(dialect, table, column)
+			if err != nil {
+				return "", "", err
+			}
+			up = append(up, fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v;", table, column.Name))
+			down = append(down, stmt)
+		}
+	}
+
+	if len(up) == 0 {
+		return "", "", nil
+	}
+
+	// undo changes in reverse order
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+
+	return strings.Join(up, "\n") + "\n", strings.Join(down, "\n") + "\n", nil
+}
+
+// addColumnStatement returns the statement adding a column, with its foreign key
+// if any. Mysql ignores references in column definitions, so the constraint is
+// added apart
+func addColumnStatement(dialect parser.Dialect, table string, column parser.Column) (string, error) {
+	if err := addableColumn(dialect, table, column); err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(column.References) == 0:
+		return fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v;", table, column.Definition), nil
+	case dialect == parser.MySQL:
+		return fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v, ADD FOREIGN KEY (%v) REFERENCES %v;",
+			table, column.Definition, column.Name, column.References), nil
+	default:
+		return fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v REFERENCES %v;", table, column.Definition, column.References), nil
+	}
+}
+
+// addableColumn returns an error if the column can't be added to a table that
+// already has registers, as it happens with required ones without a default
+// value, whose value in those registers is not known
+func addableColumn(dialect parser.Dialect, table string, column parser.Column) error {
+	definition := strings.TrimPrefix(column.Definition, column.Name+" ")
+	if strings.Contains(definition, " not null") && !strings.Contains(definition, " default ") {
+		return fmt.Errorf("Required column %v cannot be added to table %v in %v, as the value of existing registers is not known. "+
+			"Add the migration and update %v by hand", column.Name, table, dialect, schemaSnapshotFile)
+	}
+	return nil
+}
+
+// checkAddedColumns returns an error if any column of to, not being in from,
+// can't be added to the table
+func checkAddedColumns(dialect parser.Dialect, table string, from, to []parser.Column) error {
+	kept := make(map[string]bool)
+	for _, column := range from {
+		kept[column.Name] = true
+	}
+
+	for _, column := range to {
+		if kept[column.Name] {
+			continue
+		}
+		if err := addableColumn(dialect, table, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifyColumnStatement returns the statement changing a column definition and
+// its foreign key in mysql and postgres. Mysql names foreign keys after the
+// order they were created, so they cannot be changed. Neither can the types
+// of postgres columns with more than a type and not null in their definitions,
+// as those of keys
+func modifyColumnStatement(dialect parser.Dialect, table string, from, to parser.Column) (string, error) {
+	unsupported := fmt.Errorf("Column %v of table %v cannot be migrated from %q to %q in %v. Add the migration and update %v by hand",
+		to.Name, table, columnDescription(from), columnDescription(to), dialect, schemaSnapshotFile)
+
+	actions := []string{}
+	if from.Definition != to.Definition {
+		switch dialect {
+		case parser.MySQL:
+			actions = append(actions, "MODIFY COLUMN "+to.Definition)
+		case parser.Postgres:
+			fromType, fromNotNull, ok := columnType(from)
+			toType, toNotNull, toOk := columnType(to)
+			if !ok || !toOk {
+				return "", unsupported
+			}
+
+			if fromType != toType {
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %v TYPE %v USING %v::%v", to.Name, toType, to.Name, toType))
+			}
+			switch {
+			case toNotNull && !fromNotNull:
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %v SET NOT NULL", to.Name))
+			case !toNotNull && fromNotNull:
+				actions = append(actions, fmt.Sprintf("ALTER COLUMN %v DROP NOT NULL", to.Name))
+			}
+		default:
+			return "", unsupported
+		}
+	}
+
+	if from.References != to.References {
+		if dialect != parser.Postgres {
+			return "", unsupported
+		}
+
+		// postgres names foreign keys as table_column_fkey
+		if len(from.References) > 0 {
+			actions = append(actions, fmt.Sprintf("DROP CONSTRAINT IF EXISTS %v_%v_fkey", table, to.Name))
+		}
+		if len(to.References) > 0 {
+			actions = append(actions, fmt.Sprintf("ADD FOREIGN KEY (%v) REFERENCES %v", to.Name, to.References))
+		}
+	}
+
+	return fmt.Sprintf("ALTER TABLE %v %v;", table, strings.Join(actions, ", ")), nil
+}
+
+// columnType returns the SQL type of a column and whether it is not null, or
+// false if its definition holds anything else, like a primary key or a default
+func columnType(column parser.Column) (string, bool, bool) {
+	definition := strings.TrimPrefix(column.Definition, column.Name+" ")
+	notNull := strings.HasSuffix(definition, " not null")
+	definition = strings.TrimSuffix(definition, " not null")
+	if strings.Contains(definition, " primary key") || strings.Contains(definition, " default ") {
+		return "", false, false
+	}
+	return definition, notNull, true
+}
+
+// columnDescription returns the definition of a column along with its foreign key
+func columnDescription(column parser.Column) string {
+	if len(column.References) == 0 {
+		return column.Definition
+	}
+	return column.Definition + " references " + column.References
+}
+
+// rebuildTableScript returns the script changing the columns of a table by
+// copying it into a new one with the given columns, which replaces it
+func rebuildTableScript(table string, from, to []parser.Column) string {
+	kept := make(map[string]bool)
+	for _, column := range from {
+		kept[column.Name] = true
+	}
+
+	copied := []string{}
+	for _, column := range to {
+		if kept[column.Name] {
+			copied = append(copied, column.Name)
+		}
+	}
+
+	rebuilt := table + "_migrated"
+	create, _ := createTableScripts(rebuilt, to)
+	columns := strings.Join(copied, ", ")
+	return create +
+		fmt.Sprintf("INSERT INTO %v (%v) SELECT %v FROM %v;\n", rebuilt, columns, columns, table) +
+		fmt.Sprintf("DROP TABLE %v;\n", table) +
+		fmt.Sprintf("ALTER TABLE %v RENAME TO %v;\n", rebuilt, table)
+}
+
+// migrationsList returns the go declaration of the list of migrations
+func migrationsList(scripts []*migrationScript) string {
+	if len(scripts) == 0 {
+		return migrationsListMark
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("var migrations = []migration{\n")
+	for _, s := range scripts {
+		fmt.Fprintf(&buf, "{\nversion: %v,\nname: %q,\nup: %v,\ndown: %v,\n},\n",
+			s.version, s.name, goStringLiteral(s.up), goStringLiteral(s.down))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// goStringLiteral returns a raw string literal if possible, or a quoted one
+func goStringLiteral(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func init() {
+	makers.Register(&Migrations{})
+}
@@ -0,0 +1,390 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const migrationsTestContent = `package datastore
+
+// migration holds the sql scripts to upgrade and downgrade database schema
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+var migrations = []migration{}
+`
+
+type MigrationsSuite struct {
+	m *Migrations
+}
+
+var _ = check.Suite(&MigrationsSuite{})
+
+func (s *MigrationsSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.m = &Migrations{Base: makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *MigrationsSuite) TearDownTest(c *check.C) {
+	os.RemoveAll(config.Config.Output)
+}
+
+// writeFiles writes to disk the files generated by last Make call, as engine does
+func (s *MigrationsSuite) writeFiles(c *check.C) {
+	for path, b := range s.m.Files() {
+		c.Assert(io.ByteArrayToFile(b, path), check.IsNil)
+	}
+}
+
+func (s *MigrationsSuite) migrationPath(name string) string {
+	return filepath.Join(makers.BasePath, migrationsFolder, name)
+}
+
+func (s *MigrationsSuite) TestID(c *check.C) {
+	c.Assert(s.m.ID(), check.Equals, "migrations")
+}
+
+func (s *MigrationsSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.m.OutputFilepath(), check.Equals, filepath.Join(makers.BasePath, "datastore/migrations.go"))
+}
+
+func (s *MigrationsSuite) TestMake_nilGeneratedOutput(c *check.C) {
+	output, err := s.m.Make(nil, nil)
+	c.Assert(output, check.IsNil)
+	c.Assert(err, check.Equals, errs.ErrNoContent)
+}
+
+func (s *MigrationsSuite) TestMake_createTable(c *check.C) {
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.NotNil)
+
+	files := s.m.Files()
+	c.Assert(files, check.HasLen, 3)
+	c.Assert(string(files[s.migrationPath("0001_create_mytype.up.sql")]), check.Equals,
+		"CREATE TABLE IF NOT EXISTS mytype (\n"+
+			"\tid integer primary key not null,\n"+
+			"\tname varchar,\n"+
+			"\tdescription varchar,\n"+
+			"\ttheboolthing boolean,\n"+
+			"\tthefloatthing real,\n"+
+			"\trow_version integer not null default 1\n"+
+			");\n")
+	c.Assert(string(files[s.migrationPath("0001_create_mytype.down.sql")]), check.Equals, "DROP TABLE mytype;\n")
+	c.Assert(string(files[s.migrationPath(schemaSnapshotFile)]), check.Matches, `(?s)\{\n\t"mytype": \[\n.*"name": "id",.*`)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, "(?s).*version: 1,\n\t\tname:    \"create_mytype\",\n\t\tup: +`CREATE TABLE IF NOT EXISTS mytype.*")
+}
+
+func (s *MigrationsSuite) TestMake_noChanges(c *check.C) {
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	s.writeFiles(c)
+
+	generatedOutput, err = io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.m.Files(), check.HasLen, 0)
+
+	// existing migration is still registered
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "version:"), check.Equals, 1)
+}
+
+func (s *MigrationsSuite) TestMake_alterTable(c *check.C) {
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	s.writeFiles(c)
+
+	// remove a field and add a new one
+	s.m.TypeHolder.Fields = append(s.m.TypeHolder.Fields[:2], s.m.TypeHolder.Fields[3:]...)
+	s.m.TypeHolder.Fields = append(s.m.TypeHolder.Fields, parser.TypeField{Name: "Count", Type: "int64"})
+
+	generatedOutput, err = io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+
+	files := s.m.Files()
+	c.Assert(files, check.HasLen, 3)
+	c.Assert(string(files[s.migrationPath("0002_alter_mytype.up.sql")]), check.Equals,
+		"ALTER TABLE mytype ADD COLUMN count bigint;\n"+
+			"ALTER TABLE mytype DROP COLUMN description;\n")
+	c.Assert(string(files[s.migrationPath("0002_alter_mytype.down.sql")]), check.Equals,
+		"ALTER TABLE mytype ADD COLUMN description varchar;\n"+
+			"ALTER TABLE mytype DROP COLUMN count;\n")
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "version:"), check.Equals, 2)
+	c.Assert(strings.Index(str, "create_mytype") < strings.Index(str, "alter_mytype"), check.Equals, true)
+}
+
+func (s *MigrationsSuite) TestMake_pendingFiles(c *check.C) {
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+
+	// without writing files, as in dry run mode, next type takes following version
+	other := *s.m.TypeHolder
+	other.Name = "MyOtherType"
+	s.m.SetTypeHolder(&other)
+
+	generatedOutput, err = io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.m.Files()[s.migrationPath("0002_create_myothertype.up.sql")], check.NotNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Count(str, "version:"), check.Equals, 2)
+}
+
+func (s *MigrationsSuite) TestMake_duplicatedVersion(c *check.C) {
+	c.Assert(io.StringToFile("", s.migrationPath("0001_one.up.sql")), check.IsNil)
+	c.Assert(io.StringToFile("", s.migrationPath("0001_other.up.sql")), check.IsNil)
+
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.ErrorMatches, "Found several migrations with version 1")
+}
+
+func (s *MigrationsSuite) TestMake_noMigrationsList(c *check.C) {
+	generatedOutput, err := io.NewContent("package datastore\n")
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.FitsTypeOf, errs.ErrNotFound{})
+}
+
+func (s *MigrationsSuite) TestAlterTableScripts_modifiedColumn(c *check.C) {
+	previous := []parser.Column{{Name: "name", Definition: "name varchar"}}
+	current := []parser.Column{{Name: "name", Definition: "name varchar not null"}}
+
+	up, down, err := alterTableScripts(parser.MySQL, "t", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE t MODIFY COLUMN name varchar not null;\n")
+	c.Assert(down, check.Equals, "ALTER TABLE t MODIFY COLUMN name varchar;\n")
+
+	up, down, err = alterTableScripts(parser.Postgres, "t", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE t ALTER COLUMN name SET NOT NULL;\n")
+	c.Assert(down, check.Equals, "ALTER TABLE t ALTER COLUMN name DROP NOT NULL;\n")
+
+	up, down, err = alterTableScripts(parser.SQLite3, "t", current, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "")
+	c.Assert(down, check.Equals, "")
+}
+
+func (s *MigrationsSuite) TestAlterTableScripts_postgresType(c *check.C) {
+	previous := []parser.Column{{Name: "score", Definition: "score integer not null"}}
+	current := []parser.Column{{Name: "score", Definition: "score double precision"}}
+
+	up, down, err := alterTableScripts(parser.Postgres, "t", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE t ALTER COLUMN score TYPE double precision USING score::double precision, "+
+		"ALTER COLUMN score DROP NOT NULL;\n")
+	c.Assert(down, check.Equals, "ALTER TABLE t ALTER COLUMN score TYPE integer USING score::integer, "+
+		"ALTER COLUMN score SET NOT NULL;\n")
+
+	// columns with defaults or keys can't be changed
+	previous = []parser.Column{{Name: "id", Definition: "id serial primary key"}}
+	current = []parser.Column{{Name: "id", Definition: "id bigserial primary key"}}
+	_, _, err = alterTableScripts(parser.Postgres, "t", previous, current)
+	c.Assert(err, check.ErrorMatches, `Column id of table t cannot be migrated from "id serial primary key" to "id bigserial primary key" in postgres\. .*`)
+}
+
+func (s *MigrationsSuite) TestAlterTableScripts_sqlite3Rebuild(c *check.C) {
+	previous := []parser.Column{
+		{Name: "id", Definition: "id integer primary key"},
+		{Name: "name", Definition: "name varchar"},
+		{Name: "count", Definition: "count integer"},
+	}
+	current := []parser.Column{
+		{Name: "id", Definition: "id integer primary key"},
+		{Name: "name", Definition: "name varchar not null"},
+		{Name: "pages", Definition: "pages integer"},
+	}
+
+	up, down, err := alterTableScripts(parser.SQLite3, "t", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "CREATE TABLE IF NOT EXISTS t_migrated (\n"+
+		"\tid integer primary key,\n"+
+		"\tname varchar not null,\n"+
+		"\tpages integer\n"+
+		");\n"+
+		"INSERT INTO t_migrated (id, name) SELECT id, name FROM t;\n"+
+		"DROP TABLE t;\n"+
+		"ALTER TABLE t_migrated RENAME TO t;\n")
+	c.Assert(down, check.Equals, "CREATE TABLE IF NOT EXISTS t_migrated (\n"+
+		"\tid integer primary key,\n"+
+		"\tname varchar,\n"+
+		"\tcount integer\n"+
+		");\n"+
+		"INSERT INTO t_migrated (id, name) SELECT id, name FROM t;\n"+
+		"DROP TABLE t;\n"+
+		"ALTER TABLE t_migrated RENAME TO t;\n")
+}
+
+func (s *MigrationsSuite) TestCreateTableScripts_foreignKey(c *check.C) {
+	columns := []parser.Column{
+		{Name: "id", Definition: "id integer primary key not null"},
+		{Name: "authorid", Definition: "authorid integer", References: "author(id)"},
+	}
+
+	up, _ := createTableScripts("book", columns)
+	c.Assert(up, check.Equals, "CREATE TABLE IF NOT EXISTS book (\n"+
+		"\tid integer primary key not null,\n"+
+		"\tauthorid integer,\n"+
+		"\tFOREIGN KEY (authorid) REFERENCES author(id)\n"+
+		");\n")
+}
+
+func (s *MigrationsSuite) TestCreateTableScripts_compositeKey(c *check.C) {
+	columns := []parser.Column{
+		{Name: "bookid", Definition: "bookid integer not null", References: "book(id)", PrimaryKey: true},
+		{Name: "tag", Definition: "tag varchar not null", PrimaryKey: true},
+		{Name: "weight", Definition: "weight integer"},
+	}
+
+	up, _ := createTableScripts("booktag", columns)
+	c.Assert(up, check.Equals, "CREATE TABLE IF NOT EXISTS booktag (\n"+
+		"\tbookid integer not null,\n"+
+		"\ttag varchar not null,\n"+
+		"\tweight integer,\n"+
+		"\tPRIMARY KEY (bookid, tag),\n"+
+		"\tFOREIGN KEY (bookid) REFERENCES book(id)\n"+
+		");\n")
+}
+
+func (s *MigrationsSuite) TestAlterTableScripts_requiredColumn(c *check.C) {
+	previous := []parser.Column{{Name: "name", Definition: "name varchar"}}
+	current := []parser.Column{previous[0], {Name: "title", Definition: "title varchar not null"}}
+
+	for _, dialect := range []parser.Dialect{parser.SQLite3, parser.Postgres, parser.MySQL} {
+		_, _, err := alterTableScripts(dialect, "book", previous, current)
+		c.Assert(err, check.ErrorMatches, "Required column title cannot be added to table book in "+dialect.String()+
+			`, as the value of existing registers is not known\. Add the migration and update schema\.json by hand`)
+
+		// the column would be added back when reverting its removal
+		_, _, err = alterTableScripts(dialect, "book", current, previous)
+		c.Assert(err, check.ErrorMatches, "Required column title cannot be added .*")
+	}
+
+	// sqlite3 rebuilds the table when other columns change
+	changed := []parser.Column{{Name: "name", Definition: "name varchar not null"}, current[1]}
+	_, _, err := alterTableScripts(parser.SQLite3, "book", previous, changed)
+	c.Assert(err, check.ErrorMatches, "Required column title cannot be added .*")
+
+	// columns with a default value are given it
+	current[1].Definition = "title varchar not null default ''"
+	up, _, err := alterTableScripts(parser.SQLite3, "book", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE book ADD COLUMN title varchar not null default '';\n")
+}
+
+func (s *MigrationsSuite) TestAlterTableScripts_foreignKey(c *check.C) {
+	previous := []parser.Column{{Name: "name", Definition: "name varchar"}}
+	current := []parser.Column{previous[0], {Name: "authorid", Definition: "authorid integer", References: "author(id)"}}
+
+	up, down, err := alterTableScripts(parser.Postgres, "book", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE book ADD COLUMN authorid integer REFERENCES author(id);\n")
+	c.Assert(down, check.Equals, "ALTER TABLE book DROP COLUMN authorid;\n")
+
+	up, _, err = alterTableScripts(parser.MySQL, "book", previous, current)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE book ADD COLUMN authorid integer, ADD FOREIGN KEY (authorid) REFERENCES author(id);\n")
+
+	changed := []parser.Column{previous[0], {Name: "authorid", Definition: "authorid integer", References: "writer(id)"}}
+	up, down, err = alterTableScripts(parser.Postgres, "book", current, changed)
+	c.Assert(err, check.IsNil)
+	c.Assert(up, check.Equals, "ALTER TABLE book DROP CONSTRAINT IF EXISTS book_authorid_fkey, ADD FOREIGN KEY (authorid) REFERENCES writer(id);\n")
+	c.Assert(down, check.Equals, "ALTER TABLE book DROP CONSTRAINT IF EXISTS book_authorid_fkey, ADD FOREIGN KEY (authorid) REFERENCES author(id);\n")
+
+	_, _, err = alterTableScripts(parser.MySQL, "book", current, changed)
+	c.Assert(err, check.ErrorMatches, `Column authorid of table book cannot be migrated from "authorid integer references author\(id\)" `+
+		`to "authorid integer references writer\(id\)" in mysql\. Add the migration and update schema\.json by hand`)
+}
+
+func (s *MigrationsSuite) TestMake_unsupportedChange(c *check.C) {
+	s.m.TypeHolder.Dialect = parser.Postgres
+	generatedOutput, err := io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	s.writeFiles(c)
+
+	// the key changes from serial to a client supplied string
+	s.m.TypeHolder.Fields[0].Type = "string"
+
+	generatedOutput, err = io.NewContent(migrationsTestContent)
+	c.Assert(err, check.IsNil)
+
+	_, err = s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.ErrorMatches, "Column .* cannot be migrated .*")
+	c.Assert(s.m.Files(), check.HasLen, 0)
+}
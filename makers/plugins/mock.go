@@ -0,0 +1,232 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"go/ast"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/parser"
+)
+
+// Mock maker to include types in the mock implementation of datastore interface
+type Mock struct {
+	makers.Base
+}
+
+// ID returns 'mock'
+func (m *Mock) ID() string {
+	return "mock"
+}
+
+// OutputFilepath returns the path to generated file
+func (m *Mock) OutputFilepath() string {
+	return filepath.Join(makers.BasePath, "datastore/mock.go")
+}
+
+// Make generates the results, merging the fields and methods of the generated
+// Mock struct into the current one
+func (m *Mock) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	if generatedOutput == nil {
+		return nil, errs.ErrNoContent
+	}
+
+	if currentOutput == nil {
+		return generatedOutput, nil
+	}
+
+	// both outputs are reparsed so that offsets refer to their printed source.
+	// Fields and methods are merged as text, as comments are placed by their
+	// offsets when nodes are printed, and offsets of nodes of other files
+	// collide with them
+	generatedSrc, generated, err := mockReparse(generatedOutput)
+	if err != nil {
+		return nil, err
+	}
+	generatedMock := parser.GetStructType(generated.Ast, "Mock")
+	if generatedMock == nil {
+		return nil, errs.NewErrNotFound("Mock struct in generated output")
+	}
+
+	src, current, err := mockReparse(currentOutput)
+	if err != nil {
+		return nil, err
+	}
+	currentMock := parser.GetStructType(current.Ast, "Mock")
+	if currentMock == nil {
+		return nil, errs.NewErrNotFound("Mock struct in current output")
+	}
+
+	// fields and methods of the operations the type does not support anymore
+	// are removed
+	unsupported := make(map[string]bool)
+	if m.TypeHolder != nil {
+		for _, name := range m.TypeHolder.UnsupportedFuncNames() {
+			unsupported[name] = true
+		}
+	}
+
+	type splice struct {
+		start, end int
+		text       string
+	}
+	splices := []splice{}
+
+	// generated fields replace current ones, so that they follow changes of
+	// the type key, or are added at the end of the struct if not found
+	fields, fieldNames := mockFields(generatedSrc, generatedMock)
+	for _, field := range currentMock.Fields.List {
+		if len(field.Names) == 0 {
+			continue
+		}
+
+		name := field.Names[0].Name
+		start, end := mockNodeOffsets(field, field.Doc)
+		if text, ok := fields[name]; ok {
+			splices = append(splices, splice{start, end, text})
+			delete(fields, name)
+		} else if unsupported[strings.TrimSuffix(name, "Func")] {
+			// along with the line break and indentation before it
+			splices = append(splices, splice{strings.LastIndex(src[:start], "\n"), end, ""})
+		}
+	}
+
+	added := ""
+	for _, name := range fieldNames {
+		if text, ok := fields[name]; ok {
+			added += "\t" + text + "\n"
+		}
+	}
+	closing := io.FileSet.Position(currentMock.Fields.Closing).Offset
+	splices = append(splices, splice{closing, closing, added})
+
+	// the same for methods, added at the end of the file if not found
+	methods, methodNames := mockMethods(generatedSrc, generated.Ast)
+	for _, f := range parser.GetFuncDecls(current.Ast) {
+		if !isMockMethod(f) {
+			continue
+		}
+
+		name := f.Name.Name
+		start, end := mockNodeOffsets(f, f.Doc)
+		if text, ok := methods[name]; ok {
+			splices = append(splices, splice{start, end, text})
+			delete(methods, name)
+		} else if unsupported[name] {
+			splices = append(splices, splice{start, end, ""})
+		}
+	}
+
+	// from the last one, so that offsets of the previous ones remain valid
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start < splices[j].start })
+	for i := len(splices) - 1; i >= 0; i-- {
+		sp := splices[i]
+		src = src[:sp.start] + sp.text + src[sp.end:]
+	}
+
+	for _, name := range methodNames {
+		if text, ok := methods[name]; ok {
+			src += "\n" + text + "\n"
+		}
+	}
+
+	return io.NewContent(src)
+}
+
+// mockReparse returns the printed source of content and the content parsed
+// from it
+func mockReparse(content *io.Content) (string, *io.Content, error) {
+	src, err := content.String()
+	if err != nil {
+		return "", nil, err
+	}
+
+	reparsed, err := io.NewContent(src)
+	if err != nil {
+		return "", nil, err
+	}
+	return src, reparsed, nil
+}
+
+// mockFields returns the source of the fields of st, by name, and their names
+// in order
+func mockFields(src string, st *ast.StructType) (map[string]string, []string) {
+	fields := make(map[string]string)
+	names := []string{}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			continue
+		}
+
+		start, end := mockNodeOffsets(field, field.Doc)
+		fields[field.Names[0].Name] = src[start:end]
+		names = append(names, field.Names[0].Name)
+	}
+	return fields, names
+}
+
+// mockMethods returns the source of the Mock methods in file, with their doc
+// comments, by name, and their names in order
+func mockMethods(src string, file *ast.File) (map[string]string, []string) {
+	methods := make(map[string]string)
+	names := []string{}
+	for _, f := range parser.GetFuncDecls(file) {
+		if !isMockMethod(f) {
+			continue
+		}
+
+		start, end := mockNodeOffsets(f, f.Doc)
+		methods[f.Name.Name] = src[start:end]
+		names = append(names, f.Name.Name)
+	}
+	return methods, names
+}
+
+// mockNodeOffsets returns the offsets of the start of a node, including its doc
+// comment, and of its end
+func mockNodeOffsets(node ast.Node, doc *ast.CommentGroup) (int, int) {
+	pos := node.Pos()
+	if doc != nil {
+		pos = doc.Pos()
+	}
+	return io.FileSet.Position(pos).Offset, io.FileSet.Position(node.End()).Offset
+}
+
+// isMockMethod returns true if f is a method of Mock
+func isMockMethod(f *ast.FuncDecl) bool {
+	if f.Recv == nil || len(f.Recv.List) == 0 {
+		return false
+	}
+
+	recvType := f.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	ident, ok := recvType.(*ast.Ident)
+	return ok && ident.Name == "Mock"
+}
+
+func init() {
+	makers.Register(&Mock{})
+}
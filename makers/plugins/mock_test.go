@@ -0,0 +1,271 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const (
+	mockTestContent = `
+	package datastore
+
+	// Mock is a Datastore whose operations call its functions
+	type Mock struct {
+		ApplyMigrationsFunc func(ctx context.Context) error
+		GetMyTypeFunc       func(id int) (MyType, error)
+		DeleteMyTypeFunc    func(id int) error
+	}
+
+	// ApplyMigrations calls ApplyMigrationsFunc, if set
+	func (m *Mock) ApplyMigrations(ctx context.Context) error {
+		if m.ApplyMigrationsFunc == nil {
+			return nil
+		}
+		return m.ApplyMigrationsFunc(ctx)
+	}
+
+	// GetMyType calls GetMyTypeFunc
+	func (m *Mock) GetMyType(id int) (MyType, error) {
+		return m.GetMyTypeFunc(id)
+	}
+
+	// DeleteMyType calls DeleteMyTypeFunc
+	func (m *Mock) DeleteMyType(id int) error {
+		return m.DeleteMyTypeFunc(id)
+	}
+	`
+
+	mockOtherTypeTestContent = `
+	package datastore
+
+	// Mock is a Datastore whose operations call its functions
+	type Mock struct {
+		ApplyMigrationsFunc func(ctx context.Context) error
+		GetOtherTypeFunc    func(id int) (OtherType, error)
+	}
+
+	// ApplyMigrations calls ApplyMigrationsFunc, if set
+	func (m *Mock) ApplyMigrations(ctx context.Context) error {
+		if m.ApplyMigrationsFunc == nil {
+			return nil
+		}
+		return m.ApplyMigrationsFunc(ctx)
+	}
+
+	// GetOtherType calls GetOtherTypeFunc
+	func (m *Mock) GetOtherType(id int) (OtherType, error) {
+		return m.GetOtherTypeFunc(id)
+	}
+	`
+)
+
+type MockSuite struct {
+	m *Mock
+}
+
+var _ = check.Suite(&MockSuite{})
+
+func (s *MockSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.m = &Mock{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *MockSuite) TestID(c *check.C) {
+	c.Assert(s.m.ID(), check.Equals, "mock")
+}
+
+func (s *MockSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.m.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "datastore", "mock.go"))
+}
+
+func (s *MockSuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(mockOtherTypeTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "ApplyMigrationsFunc func(ctx context.Context) error"), check.Equals, 1)
+	c.Assert(strings.Count(str, "GetOtherTypeFunc"), check.Equals, 3)
+	c.Assert(strings.Count(str, "GetMyTypeFunc"), check.Equals, 3)
+	c.Assert(strings.Count(str, "DeleteMyTypeFunc"), check.Equals, 3)
+	c.Assert(strings.Count(str, "func (m *Mock) ApplyMigrations(ctx context.Context) error {"), check.Equals, 1)
+	c.Assert(strings.Count(str, "func (m *Mock) GetOtherType(id int) (OtherType, error) {"), check.Equals, 1)
+	c.Assert(strings.Count(str, "// GetMyType calls GetMyTypeFunc\nfunc (m *Mock) GetMyType(id int) (MyType, error) {"), check.Equals, 1)
+	c.Assert(strings.Count(str, "// DeleteMyType calls DeleteMyTypeFunc\nfunc (m *Mock) DeleteMyType(id int) error {"), check.Equals, 1)
+}
+
+func (s *MockSuite) TestMake_targetTypeExists(c *check.C) {
+	generatedOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "GetMyTypeFunc"), check.Equals, 3)
+	c.Assert(strings.Count(str, "// GetMyType calls GetMyTypeFunc"), check.Equals, 1)
+	c.Assert(strings.Count(str, "func (m *Mock) DeleteMyType(id int) error {"), check.Equals, 1)
+}
+
+func (s *MockSuite) TestMake_changedKey(c *check.C) {
+	generatedOutput, err := io.NewContent(strings.Replace(mockTestContent, "id int", "code string", -1))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Contains(str, "id int"), check.Equals, false)
+	c.Assert(strings.Count(str, "GetMyTypeFunc       func(code string) (MyType, error)"), check.Equals, 1)
+	c.Assert(strings.Count(str, "func (m *Mock) GetMyType(code string) (MyType, error) {"), check.Equals, 1)
+	c.Assert(strings.Index(str, "GetMyType(code") < strings.Index(str, "DeleteMyType(code"), check.Equals, true)
+}
+
+func (s *MockSuite) TestMake_unsupportedOperations(c *check.C) {
+	s.m.TypeHolder.Operations = []string{"list", "get"}
+
+	generatedOutput, err := io.NewContent(strings.NewReplacer(
+		"DeleteMyTypeFunc    func(id int) error", "",
+		`// DeleteMyType calls DeleteMyTypeFunc
+	func (m *Mock) DeleteMyType(id int) error {
+		return m.DeleteMyTypeFunc(id)
+	}`, "").Replace(mockTestContent))
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, currentOutput)
+	c.Assert(err, check.IsNil)
+
+	str, err := output.String()
+	c.Assert(err, check.IsNil)
+
+	c.Assert(strings.Count(str, "GetMyTypeFunc"), check.Equals, 3)
+	c.Assert(strings.Contains(str, "DeleteMyType"), check.Equals, false)
+}
+
+func (s *MockSuite) TestMake_nilGeneratedOutput(c *check.C) {
+	currentOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(nil, currentOutput)
+	c.Assert(output, check.IsNil)
+	c.Assert(err, check.Equals, errs.ErrNoContent)
+}
+
+func (s *MockSuite) TestMake_nilCurrentOutput(c *check.C) {
+	generatedOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *MockSuite) TestMake_currentOutputHasntMockStruct(c *check.C) {
+	generatedOutput, err := io.NewContent(mockTestContent)
+	c.Assert(err, check.IsNil)
+
+	currentOutput, err := io.NewContent("package datastore\n")
+	c.Assert(err, check.IsNil)
+
+	output, err := s.m.Make(generatedOutput, currentOutput)
+	c.Assert(output, check.IsNil)
+	_, ok := err.(errs.ErrNotFound)
+	c.Assert(ok, check.Equals, true)
+}
+
+func (s *MockSuite) TestMake_manyTypesKeepComments(c *check.C) {
+	// every type has more operations than the previous ones
+	fields := []string{}
+	content := func(name string) string {
+		fields = append(fields, "List"+name+"sFunc func() error", "Delete"+name+"Func func() error")
+		return strings.Replace(strings.Replace(mockOtherTypeTestContent,
+			"ApplyMigrationsFunc func(ctx context.Context) error",
+			"ApplyMigrationsFunc func(ctx context.Context) error\n"+strings.Join(fields, "\n"), 1),
+			"OtherType", name, -1)
+	}
+
+	current, err := io.NewContent(content("A"))
+	c.Assert(err, check.IsNil)
+
+	names := []string{"Bb", "Ccccccccccc", "Dddddddddddddddddddddd", "E", "Ffffffffffffffffffffffffffffffffffffff"}
+	for _, name := range names {
+		generatedOutput, err := io.NewContent(content(name))
+		c.Assert(err, check.IsNil)
+
+		// current output is read from the output file, after generating
+		str, err := current.String()
+		c.Assert(err, check.IsNil)
+		currentOutput, err := io.NewContent(str)
+		c.Assert(err, check.IsNil)
+
+		current, err = s.m.Make(generatedOutput, currentOutput)
+		c.Assert(err, check.IsNil)
+	}
+
+	str, err := current.String()
+	c.Assert(err, check.IsNil)
+
+	st := str[strings.Index(str, "type Mock struct {"):strings.Index(str, "\n}\n")]
+	c.Assert(strings.Contains(st, "//"), check.Equals, false)
+	for _, name := range append(names, "A") {
+		c.Assert(strings.Count(st, "\tGet"+name+"Func "), check.Equals, 1)
+		c.Assert(strings.Count(str, "// Get"+name+" calls Get"+name+"Func\nfunc (m *Mock) Get"+name+"("), check.Equals, 1)
+	}
+	c.Assert(strings.Count(str, "// ApplyMigrations calls ApplyMigrationsFunc, if set\nfunc (m *Mock) ApplyMigrations(ctx context.Context) error {"), check.Equals, 1)
+	c.Assert(strings.Count(str, "// Mock is a Datastore whose operations call its functions\ntype Mock struct {"), check.Equals, 1)
+}
@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// OpenAPI generates openapi.yaml file describing the REST API of the service
+type OpenAPI struct {
+	makers.Base
+}
+
+// ID returns 'openapi' as this maker identifier
+func (o *OpenAPI) ID() string {
+	return "openapi"
+}
+
+// OutputFilepath returns the path to generated file
+func (o *OpenAPI) OutputFilepath() string {
+	return filepath.Join(makers.BasePath, "openapi.yaml")
+}
+
+// Make is not used, as the output of this maker is not Go code
+func (o *OpenAPI) Make(generatedOutput *io.Content, currentOutput *io.Content) (*io.Content, error) {
+	return nil, errs.ErrNoContent
+}
+
+// MakeText adds the paths and schemas of the type to current specification, if
+// not there yet. Existing entries are kept as they are
+func (o *OpenAPI) MakeText(generatedOutput []byte, currentOutput []byte) ([]byte, error) {
+	if generatedOutput == nil {
+		return nil, errs.ErrNoContent
+	}
+
+	generated := yaml.MapSlice{}
+	err := yaml.Unmarshal(generatedOutput, &generated)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing generated OpenAPI specification: %v", err)
+	}
+
+	if currentOutput == nil {
+		return yaml.Marshal(generated)
+	}
+
+	current := yaml.MapSlice{}
+	err = yaml.Unmarshal(currentOutput, &current)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %v: %v", o.OutputFilepath(), err)
+	}
+
+	merged, changed := mergeOpenAPIMaps(current, generated)
+	if !changed {
+		return nil, nil
+	}
+
+	return yaml.Marshal(merged)
+}
+
+// mergeOpenAPIMaps adds to current map the generated entries it misses, merging
+// recursively the ones being maps in both. Returns true if something was added
+func mergeOpenAPIMaps(current, generated yaml.MapSlice) (yaml.MapSlice, bool) {
+	changed := false
+	for _, item := range generated {
+		i := openAPIMapIndex(current, item.Key)
+		if i < 0 {
+			current = append(current, item)
+			changed = true
+			continue
+		}
+
+		currentValue, ok := current[i].Value.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		generatedValue, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+
+		var added bool
+		current[i].Value, added = mergeOpenAPIMaps(currentValue, generatedValue)
+		changed = changed || added
+	}
+	return current, changed
+}
+
+func openAPIMapIndex(m yaml.MapSlice, key interface{}) int {
+	for i, item := range m {
+		if item.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func init() {
+	makers.Register(&OpenAPI{})
+}
@@ -0,0 +1,151 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	openAPITestContent = `openapi: 3.0.3
+info:
+  title: project
+  version: v1
+paths:
+  /v1/mytype:
+    get:
+      operationId: listMyTypes
+components:
+  schemas:
+    MyType:
+      type: object
+      properties:
+        ID: {type: integer}
+        Name: {type: string}
+`
+
+	openAPIOtherTestContent = `openapi: 3.0.3
+info:
+  title: project
+  version: v2
+paths:
+  /v1/myothertype:
+    get:
+      operationId: listMyOtherTypes
+components:
+  schemas:
+    MyOtherType:
+      type: object
+`
+)
+
+type OpenAPISuite struct {
+	o *OpenAPI
+}
+
+var _ = check.Suite(&OpenAPISuite{})
+
+func (s *OpenAPISuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.o = &OpenAPI{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *OpenAPISuite) TestID(c *check.C) {
+	c.Assert(s.o.ID(), check.Equals, "openapi")
+}
+
+func (s *OpenAPISuite) TestOutputPath(c *check.C) {
+	c.Assert(s.o.OutputFilepath(), check.Equals, filepath.Join(makers.BasePath, "openapi.yaml"))
+}
+
+func (s *OpenAPISuite) TestMake(c *check.C) {
+	output, err := s.o.Make(nil, nil)
+	c.Assert(err, check.Equals, errs.ErrNoContent)
+	c.Assert(output, check.IsNil)
+}
+
+func (s *OpenAPISuite) TestMakeText(c *check.C) {
+	output, err := s.o.MakeText([]byte(openAPITestContent), nil)
+	c.Assert(err, check.IsNil)
+
+	spec := yaml.MapSlice{}
+	c.Assert(yaml.Unmarshal(output, &spec), check.IsNil)
+	c.Assert(spec, check.HasLen, 4)
+	c.Assert(spec[0].Key, check.Equals, "openapi")
+}
+
+func (s *OpenAPISuite) TestMakeText_nilGeneratedOutput(c *check.C) {
+	output, err := s.o.MakeText(nil, []byte(openAPITestContent))
+	c.Assert(err, check.Equals, errs.ErrNoContent)
+	c.Assert(output, check.IsNil)
+}
+
+func (s *OpenAPISuite) TestMakeText_merge(c *check.C) {
+	// hand made changes in current specification must be kept
+	current := []byte(openAPITestContent + "        Extra: {type: string}\n")
+
+	output, err := s.o.MakeText([]byte(openAPIOtherTestContent), current)
+	c.Assert(err, check.IsNil)
+
+	spec := struct {
+		Info struct {
+			Version string
+		}
+		Paths      map[string]interface{}
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{}
+			}
+		}
+	}{}
+	c.Assert(yaml.Unmarshal(output, &spec), check.IsNil)
+	c.Assert(spec.Info.Version, check.Equals, "v1")
+	c.Assert(spec.Paths, check.HasLen, 2)
+	c.Assert(spec.Paths["/v1/myothertype"], check.NotNil)
+	c.Assert(spec.Components.Schemas, check.HasLen, 2)
+	c.Assert(spec.Components.Schemas["MyType"].Properties, check.HasLen, 3)
+}
+
+func (s *OpenAPISuite) TestMakeText_noChanges(c *check.C) {
+	output, err := s.o.MakeText([]byte(openAPITestContent), []byte(openAPITestContent))
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.IsNil)
+}
+
+func (s *OpenAPISuite) TestMakeText_invalidCurrentOutput(c *check.C) {
+	output, err := s.o.MakeText([]byte(openAPITestContent), []byte("paths: [unclosed"))
+	c.Assert(err, check.ErrorMatches, "Error parsing .*openapi.yaml: .*")
+	c.Assert(output, check.IsNil)
+}
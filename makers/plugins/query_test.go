@@ -0,0 +1,89 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const queryTestContent = `
+	package datastore
+
+	// ListOptions holds pagination, sorting and filtering of list operations
+	type ListOptions struct {
+		Limit  int
+		Offset int
+	}
+	`
+
+type QuerySuite struct {
+	q *Query
+}
+
+var _ = check.Suite(&QuerySuite{})
+
+func (s *QuerySuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.q = &Query{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *QuerySuite) TestID(c *check.C) {
+	c.Assert(s.q.ID(), check.Equals, "query")
+}
+
+func (s *QuerySuite) TestOutputPath(c *check.C) {
+	c.Assert(s.q.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "datastore", s.q.ID()+".go"))
+}
+
+func (s *QuerySuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(queryTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.q.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *QuerySuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(queryTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.q.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
@@ -49,16 +49,24 @@ func (r *Router) Make(generatedOutput *io.Content, currentOutput *io.Content) (*
 		// Search generated handlers amongst existing ones and add only new ones
 		stmts := getRouterFunctionStatements(currentOutput.Ast)
 		existingHandlers := findHandlersInStatements(stmts)
-		generatedHandlers := findHandlers(generatedOutput.Ast)
 		stmtsToAdd := []ast.Stmt{}
-		for k := range generatedHandlers {
-			if _, ok := existingHandlers[k]; !ok {
-				stmtsToAdd = append(stmtsToAdd, generatedHandlers[k])
+		// keep the order of the statements in the template
+		for _, stmt := range getRouterFunctionStatements(generatedOutput.Ast) {
+			for k := range findHandlersInStatements([]*ast.ExprStmt{stmt}) {
+				if _, ok := existingHandlers[k]; !ok {
+					stmtsToAdd = append(stmtsToAdd, stmt)
+					break
+				}
 			}
 		}
 
 		addStatements(currentOutput.Ast, stmtsToAdd)
 
+		// routes of the operations the type does not support anymore are removed
+		if r.TypeHolder != nil {
+			removeHandlerStatements(currentOutput.Ast, r.TypeHolder.UnsupportedFuncNames())
+		}
+
 		return currentOutput, nil
 	}
 
@@ -78,6 +86,29 @@ func addStatements(file *ast.File, stmts []ast.Stmt) {
 	}
 }
 
+// removeHandlerStatements removes the routes to any of the handlers
+func removeHandlerStatements(file *ast.File, handlers []string) {
+	r := findRouterFunction(file)
+	stmts := []ast.Stmt{}
+	for _, stmt := range r.Body.List {
+		if exprStmt, ok := stmt.(*ast.ExprStmt); ok && routesToAny(exprStmt, handlers) {
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	r.Body.List = stmts
+}
+
+func routesToAny(stmt *ast.ExprStmt, handlers []string) bool {
+	routed := findHandlersInStatements([]*ast.ExprStmt{stmt})
+	for _, h := range handlers {
+		if _, ok := routed[h]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func findRouterFunction(file *ast.File) *ast.FuncDecl {
 	funcs := parser.GetFuncDecls(file)
 	for _, f := range funcs {
@@ -100,16 +131,30 @@ func getRouterFunctionStatements(file *ast.File) []*ast.ExprStmt {
 	return stmts
 }
 
-func findHandlers(file *ast.File) map[string]*ast.ExprStmt {
-	stmts := getRouterFunctionStatements(file)
-	return findHandlersInStatements(stmts)
-}
-
 func findHandlersInStatements(stmts []*ast.ExprStmt) map[string]*ast.ExprStmt {
 	handlers := make(map[string]*ast.ExprStmt)
 	for _, s := range stmts {
-		for _, arg := range s.X.(*ast.CallExpr).Fun.(*ast.SelectorExpr).X.(*ast.CallExpr).Args {
-			for _, ident := range arg.(*ast.CallExpr).Args {
+		// generated routes are like router.Handle(path, handler).Methods(method).
+		// Any other hand made statement is ignored
+		call, ok := s.X.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		handle, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+
+		for _, arg := range handle.Args {
+			argCall, ok := arg.(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			for _, ident := range argCall.Args {
 				switch ident.(type) {
 				case *ast.SelectorExpr:
 					n := ident.(*ast.SelectorExpr).Sel.Name
@@ -22,6 +22,7 @@ package main
 import (
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"github.com/rmescandon/cruder/config"
 	"github.com/rmescandon/cruder/io"
@@ -179,6 +180,67 @@ func (s *RouterSuite) TestMake_existingOutput(c *check.C) {
 	}
 }
 
+func (s *RouterSuite) TestMake_handMadeExistingOutput(c *check.C) {
+	generatedOutput, err := io.NewContent(routerTestContent)
+	c.Assert(err, check.IsNil)
+
+	existing := strings.Replace(routerTestExistingContent, "\t\treturn router",
+		"\t\t// health check\n\t\trouter.Handle(\"/health\", http.NotFoundHandler())\n\n\t\treturn router", 1)
+	existingOutput, err := io.NewContent(existing)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.r.Make(generatedOutput, existingOutput)
+	c.Assert(err, check.IsNil)
+	c.Assert(out, check.NotNil)
+
+	stmts := getRouterFunctionStatements(out.Ast)
+	c.Assert(stmts, check.HasLen, 11)
+
+	str, err := out.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*// Router REST path multiplexer\n.*`)
+	c.Assert(str, check.Matches, `(?s).*\t// health check\n\trouter.Handle\("/health", http.NotFoundHandler\(\)\)\n.*`)
+
+	// new routes keep template order
+	create := strings.Index(str, "handler.CreateMyType")
+	list := strings.Index(str, "handler.ListMyTypes")
+	del := strings.Index(str, "handler.DeleteMyType")
+	c.Assert(create > 0 && create < list && list < del, check.Equals, true)
+}
+
+func (s *RouterSuite) TestMake_unsupportedOperations(c *check.C) {
+	s.r.TypeHolder.Operations = []string{"list", "get"}
+
+	var generated []string
+	for _, line := range strings.Split(routerTestContent, "\n") {
+		if !strings.Contains(line, "Methods(\"POST\")") && !strings.Contains(line, "Methods(\"PUT\")") &&
+			!strings.Contains(line, "Methods(\"DELETE\")") {
+			generated = append(generated, line)
+		}
+	}
+	generatedOutput, err := io.NewContent(strings.Join(generated, "\n"))
+	c.Assert(err, check.IsNil)
+
+	existing := strings.Replace(routerTestContent, "\t\treturn router",
+		"\t\trouter.Handle(\"/health\", http.NotFoundHandler())\n\n\t\treturn router", 1)
+	existingOutput, err := io.NewContent(existing)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.r.Make(generatedOutput, existingOutput)
+	c.Assert(err, check.IsNil)
+
+	stmts := getRouterFunctionStatements(out.Ast)
+	c.Assert(stmts, check.HasLen, 3)
+	m := findHandlersInStatements(stmts)
+	c.Assert(m, check.HasLen, 2)
+	c.Assert(m["ListMyTypes"], check.NotNil)
+	c.Assert(m["GetMyType"], check.NotNil)
+
+	str, err := out.String()
+	c.Assert(err, check.IsNil)
+	c.Assert(str, check.Matches, `(?s).*\trouter.Handle\("/health", http.NotFoundHandler\(\)\)\n.*`)
+}
+
 func (s *RouterSuite) TestMake_nilGeneratedOutput(c *check.C) {
 	output, err := s.r.Make(nil, nil)
 	c.Assert(err, check.IsNil)
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const versionTestContent = `
+	package datastore
+
+	const AnyVersion = 0
+	`
+
+type VersionSuite struct {
+	v *Version
+}
+
+var _ = check.Suite(&VersionSuite{})
+
+func (s *VersionSuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.v = &Version{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *VersionSuite) TestID(c *check.C) {
+	c.Assert(s.v.ID(), check.Equals, "version")
+}
+
+func (s *VersionSuite) TestOutputPath(c *check.C) {
+	c.Assert(s.v.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "datastore", "version.go"))
+}
+
+func (s *VersionSuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(versionTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.v.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *VersionSuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(versionTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.v.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rmescandon/cruder/config"
+	"github.com/rmescandon/cruder/errs"
+	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/makers"
+	"github.com/rmescandon/cruder/testdata"
+	check "gopkg.in/check.v1"
+)
+
+const versionreplyTestContent = `
+	package handler
+
+	func etag(version int) string { return "" }
+	`
+
+type VersionReplySuite struct {
+	vr *VersionReply
+}
+
+var _ = check.Suite(&VersionReplySuite{})
+
+func (s *VersionReplySuite) SetUpTest(c *check.C) {
+	typeHolder, err := testdata.TestTypeHolder()
+	c.Assert(err, check.IsNil)
+
+	config.Config.Output, err = ioutil.TempDir("", "cruder_")
+	c.Assert(err, check.IsNil)
+
+	makers.BasePath = config.Config.Output
+
+	s.vr = &VersionReply{makers.Base{TypeHolder: typeHolder}}
+}
+
+func (s *VersionReplySuite) TestID(c *check.C) {
+	c.Assert(s.vr.ID(), check.Equals, "versionreply")
+}
+
+func (s *VersionReplySuite) TestOutputPath(c *check.C) {
+	c.Assert(s.vr.OutputFilepath(),
+		check.Equals,
+		filepath.Join(makers.BasePath, "handler", "version.go"))
+}
+
+func (s *VersionReplySuite) TestMake(c *check.C) {
+	generatedOutput, err := io.NewContent(versionreplyTestContent)
+	c.Assert(err, check.IsNil)
+
+	output, err := s.vr.Make(generatedOutput, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(output, check.Equals, generatedOutput)
+}
+
+func (s *VersionReplySuite) TestMake_existingOutput(c *check.C) {
+	output, err := io.NewContent(versionreplyTestContent)
+	c.Assert(err, check.IsNil)
+
+	out, err := s.vr.Make(output, output)
+	c.Assert(out, check.IsNil)
+	_, ok := err.(errs.ErrOutputExists)
+	c.Assert(ok, check.Equals, true)
+}
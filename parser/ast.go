@@ -27,42 +27,212 @@ import (
 	"go/ast"
 	"go/printer"
 	"go/token"
+	"strconv"
 
+	"github.com/rmescandon/cruder/errs"
 	"github.com/rmescandon/cruder/io"
+	"github.com/rmescandon/cruder/log"
 )
 
-// ComposeTypeHolders composes the type holders for the types in source file
-func ComposeTypeHolders(source *io.GoFile) ([]*TypeHolder, error) {
+// ComposeTypeHolders composes the type holders for the types in source files.
+// Types can embed or reference the ones declared in any of the files
+func ComposeTypeHolders(sources ...*io.GoFile) ([]*TypeHolder, error) {
+	source, err := mergeSources(sources)
+	if err != nil {
+		return []*TypeHolder{}, err
+	}
+
 	var holders []*TypeHolder
 	decls := getStructs(source.Ast)
+	embedded := getEmbeddedTypeNames(source.Ast)
+
+	directives, err := getTypeDirectives(decls)
+	if err != nil {
+		return []*TypeHolder{}, err
+	}
+	resourcesOnly := false
+	for _, d := range directives {
+		if _, ok := d[resourceDirective]; ok {
+			resourcesOnly = true
+		}
+	}
 
 	for _, decl := range decls {
 		for _, spec := range decl.Specs {
-			var buf bytes.Buffer
-			printer.Fprint(&buf, token.NewFileSet(), spec)
+			if _, ok := spec.(*ast.TypeSpec).Type.(*ast.StructType); !ok {
+				continue
+			}
 
-			fields, err := composeTypeFields(spec)
+			// types embedded into others are flattened into them, not generated
+			if embedded[spec.(*ast.TypeSpec).Name.Name] {
+				continue
+			}
+
+			// types can be excluded with directives in their doc comment
+			d := directives[spec.(*ast.TypeSpec).Name.Name]
+			if _, ok := d[skipDirective]; ok {
+				continue
+			}
+			if _, ok := d[resourceDirective]; resourcesOnly && !ok {
+				continue
+			}
+
+			name := spec.(*ast.TypeSpec).Name.Name
+			operations, err := typeOperations(name, d)
 			if err != nil {
 				return []*TypeHolder{}, err
 			}
 
-			// validate that there are at least two fields,
-			// first will be taken as the ID and second as the search field
-			name := spec.(*ast.TypeSpec).Name.Name
+			fields, err := composeTypeFields(spec, source.Ast)
+			if err != nil {
+				return []*TypeHolder{}, err
+			}
+
+			// validate that there are at least two fields, as the key fields,
+			// the ones tagged as id or the first one, can't be all of them
 			if len(fields) < 2 {
 				return holders, fmt.Errorf("Found less than 2 fields for type %v", name)
 			}
 
+			if err := checkKey(name, fields); err != nil {
+				return holders, err
+			}
+
+			_, audit := d[auditDirective]
+			if err := checkReservedColumns(name, fields, audit); err != nil {
+				return holders, err
+			}
+
+			if err := checkReservedWords(name, fields); err != nil {
+				return holders, err
+			}
+
+			for _, f := range fields {
+				if f.Tags.Search && f.Type != "string" {
+					return holders, fmt.Errorf("Field %v of type %v cannot be searched as it is not a string", f.Name, name)
+				}
+			}
+
 			holders = append(holders, &TypeHolder{
-				Name:   name,
-				Source: source,
-				Fields: fields,
-				Decl:   decl,
+				Name:       name,
+				Source:     source,
+				Fields:     fields,
+				Decl:       flattenDecl(decl, spec.(*ast.TypeSpec), source.Ast),
+				Operations: operations,
+				Audit:      audit,
 			})
 		}
 	}
 
-	return holders, nil
+	if err := resolveReferences(holders); err != nil {
+		return []*TypeHolder{}, err
+	}
+
+	return sortByReferences(holders)
+}
+
+// SelectTypeHolders returns the holders of the types with names in only, or
+// all of them if only is empty, leaving out the ones with names in exclude.
+// Holders keep their order
+func SelectTypeHolders(holders []*TypeHolder, only []string, exclude []string) ([]*TypeHolder, error) {
+	byName := make(map[string]*TypeHolder)
+	for _, holder := range holders {
+		byName[holder.Name] = holder
+	}
+
+	for _, name := range append(append([]string{}, only...), exclude...) {
+		if _, ok := byName[name]; !ok {
+			return []*TypeHolder{}, fmt.Errorf("Type %v not found in types files", name)
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, holder := range holders {
+		selected[holder.Name] = len(only) == 0
+	}
+	for _, name := range only {
+		selected[name] = true
+	}
+	for _, name := range exclude {
+		selected[name] = false
+	}
+
+	var result []*TypeHolder
+	for _, holder := range holders {
+		if !selected[holder.Name] {
+			continue
+		}
+		for _, field := range holder.References() {
+			if !selected[field.Ref.Type] {
+				log.Warningf("Type %v references %v, which is not selected. Its table has to exist already", holder.Name, field.Ref.Type)
+			}
+		}
+		result = append(result, holder)
+	}
+	return result, nil
+}
+
+// mergeSources returns a source holding the declarations and imports of all
+// the sources, as the files of a package share their scope
+func mergeSources(sources []*io.GoFile) (*io.GoFile, error) {
+	if len(sources) == 0 {
+		return nil, errs.NewErrNotFound("Go source files")
+	}
+	if len(sources) == 1 {
+		return sources[0], nil
+	}
+
+	merged := &ast.File{Name: sources[0].Ast.Name}
+	declared := make(map[string]string)
+	// import paths by the name they are referred by, and the files importing them
+	imported := make(map[string]string)
+	importedIn := make(map[string]string)
+	for _, source := range sources {
+		for _, decl := range getTypeDecls(source.Ast) {
+			for _, spec := range decl.Specs {
+				name := spec.(*ast.TypeSpec).Name.Name
+				if path, ok := declared[name]; ok {
+					return nil, fmt.Errorf("Type %v declared in both %v and %v", name, path, source.Path)
+				}
+				declared[name] = source.Path
+			}
+		}
+
+		merged.Decls = append(merged.Decls, source.Ast.Decls...)
+		for _, imp := range source.Ast.Imports {
+			// same import can appear in several files, but a name can't refer
+			// to different packages, as the types of all files are merged
+			name := importName(imp)
+			path, ok := imported[name]
+			switch {
+			case name == "_" || name == ".":
+				if !hasImportPath(merged.Imports, imp.Path.Value) {
+					merged.Imports = append(merged.Imports, imp)
+				}
+			case !ok:
+				imported[name] = imp.Path.Value
+				importedIn[name] = source.Path
+				merged.Imports = append(merged.Imports, imp)
+			case path != imp.Path.Value:
+				return nil, fmt.Errorf("Import name %v refers to %v in %v and to %v in %v",
+					name, path, importedIn[name], imp.Path.Value, source.Path)
+			}
+		}
+	}
+
+	return &io.GoFile{
+		Content: io.Content{Ast: merged, Fset: sources[0].Fset},
+	}, nil
+}
+
+// hasImportPath returns true if any of the imports has the given quoted path
+func hasImportPath(imports []*ast.ImportSpec, path string) bool {
+	for _, imp := range imports {
+		if imp.Path.Value == path {
+			return true
+		}
+	}
+	return false
 }
 
 func getTypeDecls(file *ast.File) []*ast.GenDecl {
@@ -83,9 +253,9 @@ func getStructs(file *ast.File) []*ast.GenDecl {
 	typeDecls := getTypeDecls(file)
 	for _, decl := range typeDecls {
 		for _, spec := range decl.Specs {
-			switch spec.(*ast.TypeSpec).Type.(type) {
-			case *ast.StructType:
+			if _, ok := spec.(*ast.TypeSpec).Type.(*ast.StructType); ok {
 				structs = append(structs, decl)
+				break
 			}
 		}
 	}
@@ -158,6 +328,31 @@ func HasMethod(iface *ast.InterfaceType, methodName string) bool {
 	return false
 }
 
+// AddImport adds an import to file if it is not already imported
+func AddImport(file *ast.File, spec *ast.ImportSpec) {
+	for _, imp := range file.Imports {
+		if imp.Path.Value == spec.Path.Value {
+			return
+		}
+	}
+
+	var importDecl *ast.GenDecl
+	for _, decl := range file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.IMPORT {
+			importDecl = genDecl
+			break
+		}
+	}
+
+	if importDecl == nil {
+		importDecl = &ast.GenDecl{Tok: token.IMPORT}
+		file.Decls = append([]ast.Decl{importDecl}, file.Decls...)
+	}
+
+	importDecl.Specs = append(importDecl.Specs, spec)
+	file.Imports = append(file.Imports, spec)
+}
+
 // AddMethod modyfies iface by adding method
 func AddMethod(iface *ast.InterfaceType, method *ast.Field) {
 	if iface.Methods == nil {
@@ -169,17 +364,340 @@ func AddMethod(iface *ast.InterfaceType, method *ast.Field) {
 	}
 }
 
-func composeTypeFields(spec ast.Spec) ([]TypeField, error) {
+// ReplaceMethod modifies iface by replacing the method with the same name as
+// the given one, in the same position, or by adding it if there is none
+func ReplaceMethod(iface *ast.InterfaceType, method *ast.Field) {
+	if iface.Methods != nil {
+		for i, m := range iface.Methods.List {
+			if len(m.Names) > 0 && len(method.Names) > 0 && m.Names[0].Name == method.Names[0].Name {
+				iface.Methods.List[i] = method
+				return
+			}
+		}
+	}
+	AddMethod(iface, method)
+}
+
+// RemoveMethod modifies iface by removing the method with certain name, if any
+func RemoveMethod(iface *ast.InterfaceType, methodName string) {
+	if iface.Methods == nil {
+		return
+	}
+
+	methods := []*ast.Field{}
+	for _, method := range iface.Methods.List {
+		if len(method.Names) > 0 && method.Names[0].Name == methodName {
+			continue
+		}
+		methods = append(methods, method)
+	}
+	iface.Methods.List = methods
+}
+
+// UsedNames returns the names, out of the given ones, of the identifiers used
+// or declared in file
+func UsedNames(file *ast.File, names []string) []string {
+	found := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			found[ident.Name] = true
+		}
+		return true
+	})
+
+	used := []string{}
+	for _, name := range names {
+		if found[name] {
+			used = append(used, name)
+		}
+	}
+	return used
+}
+
+// ReplaceField modifies st by replacing the field with the same name as the
+// given one, in the same position, or by adding it if there is none
+func ReplaceField(st *ast.StructType, field *ast.Field) {
+	for i, f := range st.Fields.List {
+		if len(f.Names) > 0 && len(field.Names) > 0 && f.Names[0].Name == field.Names[0].Name {
+			st.Fields.List[i] = field
+			return
+		}
+	}
+	st.Fields.List = append(st.Fields.List, field)
+}
+
+// RemoveField modifies st by removing the field with certain name, if any
+func RemoveField(st *ast.StructType, fieldName string) {
+	fields := []*ast.Field{}
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 && field.Names[0].Name == fieldName {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	st.Fields.List = fields
+}
+
+func composeTypeFields(spec ast.Spec, file *ast.File) ([]TypeField, error) {
+	typeSpec := spec.(*ast.TypeSpec)
+	visited := map[string]bool{typeSpec.Name.Name: true}
+	return composeStructFields(typeSpec.Type.(*ast.StructType), file, visited)
+}
+
+// composeStructFields returns the fields of a struct, expanding grouped names
+// like "A, B string" and flattening embedded structs declared in the sources
+func composeStructFields(st *ast.StructType, file *ast.File, visited map[string]bool) ([]TypeField, error) {
+	// names declared directly in this struct shadow the promoted ones
+	declared := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			declared[name.Name] = true
+		}
+	}
+
 	var fields []TypeField
-	for _, field := range spec.(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
-		if len(field.Names) != 1 {
-			return []TypeField{}, fmt.Errorf("Unexpected length of %v for a field", len(field.Names))
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			embedded, err := composeEmbeddedFields(field, file, visited)
+			if err != nil {
+				return []TypeField{}, err
+			}
+
+			for _, f := range embedded {
+				if !declared[f.Name] {
+					fields = append(fields, f)
+				}
+			}
+			continue
 		}
 
-		fields = append(fields, TypeField{
-			Name: field.Names[0].Name,
-			Type: fmt.Sprintf("%s", field.Type),
-		})
+		tags, err := composeFieldTags(field)
+		if err != nil {
+			return []TypeField{}, fmt.Errorf("Field %v: %v", field.Names[0].Name, err)
+		}
+
+		for _, name := range field.Names {
+			f := TypeField{
+				Name: name.Name,
+				Type: exprToString(field.Type),
+				Tags: tags,
+			}
+			if err := f.checkRules(); err != nil {
+				return []TypeField{}, err
+			}
+			fields = append(fields, f)
+		}
 	}
 	return fields, nil
 }
+
+func composeEmbeddedFields(field *ast.Field, file *ast.File, visited map[string]bool) ([]TypeField, error) {
+	ident, ok := field.Type.(*ast.Ident)
+	if !ok {
+		log.Warningf("Embedded field %v cannot be flattened. Skipped", exprToString(field.Type))
+		return []TypeField{}, nil
+	}
+
+	st := GetStructType(file, ident.Name)
+	if st == nil {
+		log.Warningf("Embedded type %v is not a struct declared in types files. Skipped", ident.Name)
+		return []TypeField{}, nil
+	}
+
+	if visited[ident.Name] {
+		return []TypeField{}, fmt.Errorf("Invalid recursive embedding of type %v", ident.Name)
+	}
+	visited[ident.Name] = true
+	defer delete(visited, ident.Name)
+
+	return composeStructFields(st, file, visited)
+}
+
+// flattenDecl returns the declaration of the type, replacing embedded structs
+// declared in the sources by their fields. If there is nothing to flatten,
+// original declaration is returned
+func flattenDecl(decl *ast.GenDecl, spec *ast.TypeSpec, file *ast.File) *ast.GenDecl {
+	st := spec.Type.(*ast.StructType)
+	if !hasEmbeddedStructs(st, file) {
+		return decl
+	}
+
+	flattened := *spec
+	flattened.Type = &ast.StructType{
+		Struct: st.Struct,
+		Fields: &ast.FieldList{
+			Opening: st.Fields.Opening,
+			List:    flattenFieldList(st, file, map[string]bool{spec.Name.Name: true}),
+			Closing: st.Fields.Closing,
+		},
+	}
+
+	return &ast.GenDecl{
+		Doc:    decl.Doc,
+		TokPos: decl.TokPos,
+		Tok:    token.TYPE,
+		Specs:  []ast.Spec{&flattened},
+	}
+}
+
+func hasEmbeddedStructs(st *ast.StructType, file *ast.File) bool {
+	for _, field := range st.Fields.List {
+		if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 {
+			if GetStructType(file, ident.Name) != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flattenFieldList does the same as composeStructFields but over syntax tree fields
+func flattenFieldList(st *ast.StructType, file *ast.File, visited map[string]bool) []*ast.Field {
+	declared := make(map[string]bool)
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			declared[name.Name] = true
+		}
+	}
+
+	var list []*ast.Field
+	for _, field := range st.Fields.List {
+		ident, ok := field.Type.(*ast.Ident)
+		if !ok || len(field.Names) > 0 || visited[ident.Name] {
+			list = append(list, field)
+			continue
+		}
+
+		embedded := GetStructType(file, ident.Name)
+		if embedded == nil {
+			list = append(list, field)
+			continue
+		}
+
+		visited[ident.Name] = true
+		for _, f := range flattenFieldList(embedded, file, visited) {
+			if len(f.Names) > 0 && declared[f.Names[0].Name] {
+				continue
+			}
+			list = append(list, f)
+		}
+		delete(visited, ident.Name)
+	}
+	return list
+}
+
+// getEmbeddedTypeNames returns the names of the types embedded by the structs of file
+func getEmbeddedTypeNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range getStructs(file) {
+		for _, spec := range decl.Specs {
+			st, ok := spec.(*ast.TypeSpec).Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			for _, field := range st.Fields.List {
+				if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 {
+					names[ident.Name] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// GetStructType returns the struct declared in file with certain name, or nil
+// if there is none
+func GetStructType(file *ast.File, name string) *ast.StructType {
+	for _, decl := range getStructs(file) {
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if typeSpec.Name.Name != name {
+				continue
+			}
+
+			if st, ok := typeSpec.Type.(*ast.StructType); ok {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// exprToString returns the go source representation of an expression, like
+// "*string", "[]int" or "time.Time"
+func exprToString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+func composeFieldTags(field *ast.Field) (FieldTags, error) {
+	if field.Tag == nil {
+		return FieldTags{}, nil
+	}
+
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return FieldTags{}, err
+	}
+
+	return parseFieldTags(tag)
+}
+
+// checkReservedColumns returns an error if any field of a type is stored in a
+// column kept for the version of the registers or, if it keeps audit times, for
+// these ones
+func checkReservedColumns(name string, fields []TypeField, audit bool) error {
+	reserved := map[string]string{VersionColumn: "versions"}
+	if audit {
+		for _, column := range []string{CreatedAtColumn, UpdatedAtColumn, DeletedAtColumn} {
+			reserved[column] = "audit times"
+		}
+	}
+
+	for _, field := range fields {
+		if kept, ok := reserved[field.ColumnName()]; ok {
+			return fmt.Errorf("Field %v of type %v cannot be stored in column %v, kept for %v", field.Name, name, field.ColumnName(), kept)
+		}
+	}
+	return nil
+}
+
+// checkReservedWords returns an error if the table of a type or the column of
+// any of its fields is named as an SQL keyword
+func checkReservedWords(name string, fields []TypeField) error {
+	holder := TypeHolder{Name: name}
+	if IsReserved(holder.TableName()) {
+		return fmt.Errorf("Type %v cannot be stored in table %v, a reserved word of SQL", name, holder.TableName())
+	}
+
+	for _, field := range fields {
+		if IsReserved(field.ColumnName()) {
+			return fmt.Errorf("Field %v of type %v cannot be stored in column %v, a reserved word of SQL", field.Name, name, field.ColumnName())
+		}
+	}
+	return nil
+}
+
+// checkKey returns an error if the key fields of a type, the ones tagged as id
+// or the first one, cannot be used as its primary key
+func checkKey(name string, fields []TypeField) error {
+	holder := TypeHolder{Name: name, Fields: fields}
+	keyFields := holder.KeyFields()
+	if len(keyFields) == len(fields) {
+		return fmt.Errorf("Found no field out of the key for type %v", name)
+	}
+
+	for _, f := range keyFields {
+		switch {
+		case len(keyFields) > 1 && f.Type != "int" && f.Type != "int64" && f.Type != "string":
+			return fmt.Errorf("Field %v of type %v cannot be part of a composite key as it is not an int, int64 or string", f.Name, name)
+		case f.Tags.UUID && f.Type != "string":
+			return fmt.Errorf("Field %v of type %v cannot hold uuids as it is not a string", f.Name, name)
+		case f.Tags.UUID && len(keyFields) > 1:
+			return fmt.Errorf("Field %v of type %v cannot hold uuids as part of a composite key", f.Name, name)
+		}
+	}
+	return nil
+}
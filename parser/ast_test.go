@@ -230,6 +230,41 @@ func (s *AstSuite) TestUpdateInterfaceMethods(c *check.C) {
 
 	fields := GetInterfaceMethods(iface)
 	c.Assert(fields, check.HasLen, 2)
+
+	RemoveMethod(iface, "NotExistingFunc")
+	c.Assert(GetInterfaceMethods(iface), check.HasLen, 2)
+
+	RemoveMethod(iface, "MyFunc")
+	c.Assert(GetInterfaceMethods(iface), check.HasLen, 1)
+	c.Assert(HasMethod(iface, "MyFunc"), check.Equals, false)
+}
+
+func (s *AstSuite) TestUpdateStructFields(c *check.C) {
+	content, err := io.NewContent(astTestContent)
+	c.Assert(err, check.IsNil)
+
+	st := GetStructType(content.Ast, "MyType")
+	c.Assert(st, check.NotNil)
+	c.Assert(GetStructType(content.Ast, "MyInterface"), check.IsNil)
+
+	other, err := io.NewContent("package datastore\n\ntype Other struct {\n\tName []byte\n\tExtra int\n}\n")
+	c.Assert(err, check.IsNil)
+	otherFields := GetStructType(other.Ast, "Other").Fields.List
+
+	ReplaceField(st, otherFields[0])
+	c.Assert(st.Fields.List, check.HasLen, 4)
+	c.Assert(st.Fields.List[1], check.Equals, otherFields[0])
+
+	ReplaceField(st, otherFields[1])
+	c.Assert(st.Fields.List, check.HasLen, 5)
+	c.Assert(st.Fields.List[4], check.Equals, otherFields[1])
+
+	RemoveField(st, "NotExistingField")
+	c.Assert(st.Fields.List, check.HasLen, 5)
+
+	RemoveField(st, "Description")
+	c.Assert(st.Fields.List, check.HasLen, 4)
+	c.Assert(st.Fields.List[2].Names[0].Name, check.Equals, "SubTypes")
 }
 
 func (s *AstSuite) TestComposeTypeHolder(c *check.C) {
@@ -259,3 +294,363 @@ func (s *AstSuite) TestComposeTypeHolder(c *check.C) {
 	c.Assert(th, check.HasLen, 1)
 	c.Assert(th[0].Name, check.Equals, "MyType")
 }
+
+func (s *AstSuite) TestComposeTypeHolder_tags(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		Name string ` + "`cruder:\"search,column=full_name\" json:\"name\"`" + `
+		Code string ` + "`cruder:\"id\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	th, err := ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 1)
+	c.Assert(th[0].Fields[0].Tags, check.DeepEquals, FieldTags{Search: true, Column: "full_name", JSON: "name"})
+	c.Assert(th[0].Fields[1].Tags, check.DeepEquals, FieldTags{ID: true})
+	c.Assert(th[0].IDFieldName(), check.Equals, "Code")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_invalidTag(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID   int
+		Name string ` + "`cruder:\"whatever\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field Name: Unknown option \"whatever\" in cruder tag")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_compositeKey(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID    int    ` + "`cruder:\"id\"`" + `
+		Name  string ` + "`cruder:\"id\"`" + `
+		Value int
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	th, err := ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 1)
+	c.Assert(th[0].CompositeKey(), check.Equals, true)
+	c.Assert(th[0].KeyVars(), check.Equals, "id, name")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_onlyKeyFields(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID   int    ` + "`cruder:\"id\"`" + `
+		Name string ` + "`cruder:\"id\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Found no field out of the key for type MyType")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_compositeKeyType(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID    int     ` + "`cruder:\"id\"`" + `
+		Price float64 ` + "`cruder:\"id\"`" + `
+		Name  string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field Price of type MyType cannot be part of a composite key as it is not an int, int64 or string")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_uuidNotString(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID   int ` + "`cruder:\"id=uuid\"`" + `
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field ID of type MyType cannot hold uuids as it is not a string")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_uuidInCompositeKey(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID    string ` + "`cruder:\"id=uuid\"`" + `
+		Code  string ` + "`cruder:\"id\"`" + `
+		Name  string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field ID of type MyType cannot hold uuids as part of a composite key")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_searchNotString(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID    int
+		Count int ` + "`cruder:\"search\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field Count of type MyType cannot be searched as it is not a string")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_reservedTable(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type Order struct {
+		ID    int
+		Total int
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Type Order cannot be stored in table order, a reserved word of SQL")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_reservedColumn(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID    int
+		Group string
+		Key   string ` + "`cruder:\"column=key_name\"`" + `
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Field Group of type MyType cannot be stored in column group, a reserved word of SQL")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_fieldTypes(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type Audit struct {
+		Created time.Time
+		Name    string
+	}
+
+	type MyType struct {
+		ID          int
+		Name, Alias string
+		Parent      *string
+		Tags        []string
+		Ext         pkg.External
+		Audit
+		sync.Mutex
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	th, err := ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 1)
+	c.Assert(th[0].Name, check.Equals, "MyType")
+	c.Assert(th[0].Fields, check.DeepEquals, []TypeField{
+		{Name: "ID", Type: "int"},
+		{Name: "Name", Type: "string"},
+		{Name: "Alias", Type: "string"},
+		{Name: "Parent", Type: "*string"},
+		{Name: "Tags", Type: "[]string"},
+		{Name: "Ext", Type: "pkg.External"},
+		{Name: "Created", Type: "time.Time"},
+	})
+	c.Assert(th[0].IDFieldName(), check.Equals, "ID")
+}
+
+func (s *AstSuite) TestComposeTypeHolder_sampleImports(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	import (
+		"time"
+
+		ext "github.com/some/pkg"
+		"github.com/some/uuid"
+	)
+
+	type MyType struct {
+		ID      uuid.UUID
+		Ext     *ext.External
+		Created time.Time
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	th, err := ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 1)
+	c.Assert(th[0].SampleImports(), check.DeepEquals, []string{`"time"`, `ext "github.com/some/pkg"`})
+}
+
+func (s *AstSuite) TestComposeTypeHolder_recursiveEmbedding(c *check.C) {
+	content, err := io.NewContent(`
+	package mytype
+
+	type MyType struct {
+		ID int
+		A
+	}
+
+	type A struct {
+		B
+		Name string
+	}
+
+	type B struct {
+		A
+		Description string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	_, err = ComposeTypeHolders(&io.GoFile{Content: *content})
+	c.Assert(err, check.ErrorMatches, "Invalid recursive embedding of type .*")
+}
+
+func (s *AstSuite) TestComposeTypeHolders_severalSources(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	type Book struct {
+		ID       int
+		Title    string
+		AuthorID int
+		Audit
+	}
+	`, `
+	package model
+
+	import "time"
+
+	type Audit struct {
+		CreatedAt time.Time
+	}
+
+	type Author struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 2)
+
+	c.Assert(th[0].Name, check.Equals, "Author")
+	c.Assert(th[1].Name, check.Equals, "Book")
+	c.Assert(th[1].Fields, check.HasLen, 4)
+	c.Assert(th[1].Fields[3].Name, check.Equals, "CreatedAt")
+	c.Assert(th[1].Fields[2].Ref, check.DeepEquals, &Reference{Type: "Author", Table: "author", Column: "id"})
+
+	imports := th[1].Imports()
+	c.Assert(imports, check.HasLen, 1)
+	c.Assert(imports[0].Path.Value, check.Equals, `"time"`)
+}
+
+func (s *AstSuite) TestComposeTypeHolders_duplicatedType(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package model
+
+	type Book struct {
+		ID    int
+		Title string
+	}
+	`, `
+	package model
+
+	type Book struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Type Book declared in both file0.go and file1.go")
+}
+
+func (s *AstSuite) TestComposeTypeHolders_clashingImports(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package model
+
+	import "github.com/some/pkg"
+
+	type Book struct {
+		ID    int
+		Title pkg.Title
+	}
+	`, `
+	package model
+
+	import pkg "github.com/other/pkg"
+
+	type Author struct {
+		ID   int
+		Name pkg.Name
+	}
+	`)
+	c.Assert(err, check.ErrorMatches,
+		`Import name pkg refers to "github.com/some/pkg" in file0.go and to "github.com/other/pkg" in file1.go`)
+}
+
+func (s *AstSuite) TestComposeTypeHolders_noSources(c *check.C) {
+	_, err := ComposeTypeHolders()
+	c.Assert(err, check.NotNil)
+}
+
+func (s *AstSuite) TestSelectTypeHolders(c *check.C) {
+	holders := []*TypeHolder{{Name: "Author"}, {Name: "Book"}, {Name: "Editor"}}
+
+	selected, err := SelectTypeHolders(holders, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(selected, check.DeepEquals, holders)
+
+	selected, err = SelectTypeHolders(holders, []string{"Editor", "Author"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(selected, check.DeepEquals, []*TypeHolder{holders[0], holders[2]})
+
+	selected, err = SelectTypeHolders(holders, nil, []string{"Book"})
+	c.Assert(err, check.IsNil)
+	c.Assert(selected, check.DeepEquals, []*TypeHolder{holders[0], holders[2]})
+
+	selected, err = SelectTypeHolders(holders, []string{"Editor", "Author"}, []string{"Editor"})
+	c.Assert(err, check.IsNil)
+	c.Assert(selected, check.DeepEquals, []*TypeHolder{holders[0]})
+
+	_, err = SelectTypeHolders(holders, []string{"Magazine"}, nil)
+	c.Assert(err, check.ErrorMatches, "Type Magazine not found in types files")
+
+	_, err = SelectTypeHolders(holders, nil, []string{"Magazine"})
+	c.Assert(err, check.ErrorMatches, "Type Magazine not found in types files")
+}
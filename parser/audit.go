@@ -0,0 +1,38 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+// Columns added to the tables of types with the audit directive. They are
+// managed by the datastore, out of the type fields
+const (
+	CreatedAtColumn = "created_at"
+	UpdatedAtColumn = "updated_at"
+	// DeletedAtColumn is null until the register is deleted softly
+	DeletedAtColumn = "deleted_at"
+)
+
+// auditColumns returns the columns holding the audit times of the registers
+func (holder *TypeHolder) auditColumns() []Column {
+	columns := []Column{}
+	for _, name := range []string{CreatedAtColumn, UpdatedAtColumn, DeletedAtColumn} {
+		columns = append(columns, Column{Name: name, Definition: name + " " + holder.Dialect.SQLType("time.Time")})
+	}
+	return columns
+}
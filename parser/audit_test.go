@@ -0,0 +1,86 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type AuditSuite struct{}
+
+var _ = check.Suite(&AuditSuite{})
+
+func (s *AuditSuite) TestComposeTypeHolders_audit(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	// Book keeps its history
+	//cruder:audit
+	type Book struct {
+		ID    int
+		Title string
+	}
+
+	type Author struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 2)
+	c.Assert(th[0].Audit, check.Equals, true)
+	c.Assert(th[1].Audit, check.Equals, false)
+}
+
+func (s *AuditSuite) TestComposeTypeHolders_auditColumnTaken(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package model
+
+	//cruder:audit
+	type Book struct {
+		ID      int
+		Title   string
+		Created string `+"`cruder:\"column=created_at\"`"+`
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Field Created of type Book cannot be stored in column created_at, kept for audit times")
+}
+
+func (s *AuditSuite) TestColumns(c *check.C) {
+	holder := &TypeHolder{
+		Name:    "Book",
+		Dialect: MySQL,
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "Title", Type: "string"},
+		},
+		Audit: true,
+	}
+	c.Assert(holder.Columns(), check.DeepEquals, []Column{
+		{Name: "id", Definition: "id integer not null auto_increment primary key"},
+		{Name: "title", Definition: "title varchar(255)"},
+		{Name: "row_version", Definition: "row_version integer not null default 1"},
+		{Name: "created_at", Definition: "created_at datetime"},
+		{Name: "updated_at", Definition: "updated_at datetime"},
+		{Name: "deleted_at", Definition: "deleted_at datetime"},
+	})
+	c.Assert(holder.InsertColumns(), check.Equals, "title, created_at, updated_at")
+	c.Assert(holder.InsertParams(), check.Equals, "?, current_timestamp, current_timestamp")
+}
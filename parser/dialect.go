@@ -0,0 +1,235 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the SQL flavour of the database generated code works with. Its
+// name is the one of the driver used when opening the database
+type Dialect string
+
+// Supported dialects
+const (
+	SQLite3  Dialect = "sqlite3"
+	Postgres Dialect = "postgres"
+	MySQL    Dialect = "mysql"
+)
+
+// DefaultDialect is the dialect used when none is set
+const DefaultDialect = SQLite3
+
+var dialectDrivers = map[Dialect]string{
+	SQLite3:  "github.com/mattn/go-sqlite3",
+	Postgres: "github.com/lib/pq",
+	MySQL:    "github.com/go-sql-driver/mysql",
+}
+
+// reservedWords are the keywords of any of the dialects which cannot be used as
+// table or column names, as generated statements do not quote them
+var reservedWords = wordSet(
+	"accessible", "add", "all", "alter", "analyse", "analyze", "and", "any", "array", "as", "asc",
+	"asymmetric", "authorization", "autoincrement", "before", "between", "bigint", "binary", "blob",
+	"both", "by", "call", "cascade", "case", "cast", "change", "char", "character", "check",
+	"collate", "collation", "column", "commit", "concurrently", "condition", "constraint",
+	"continue", "convert", "create", "cross", "cube", "current_catalog", "current_date",
+	"current_role", "current_schema", "current_time", "current_timestamp", "current_user", "cursor",
+	"database", "databases", "dec", "decimal", "declare", "default", "deferrable", "delayed",
+	"delete", "desc", "describe", "distinct", "div", "do", "double", "drop", "dual", "each", "else",
+	"elseif", "empty", "enclosed", "end", "escape", "escaped", "except", "exists", "exit",
+	"explain", "false", "fetch", "float", "for", "force", "foreign", "freeze", "from", "full",
+	"function", "generated", "get", "grant", "group", "groups", "having", "if", "ignore", "ilike",
+	"in", "index", "infile", "initially", "inner", "inout", "insert", "int", "integer",
+	"intersect", "interval", "into", "is", "isnull", "iterate", "join", "key", "keys", "kill",
+	"lag", "lateral", "lead", "leading", "leave", "left", "like", "limit", "lines", "load",
+	"localtime", "localtimestamp", "lock", "long", "loop", "match", "mod", "modifies", "natural",
+	"not", "notnull", "null", "of", "offset", "on", "only", "option", "optionally", "or", "order",
+	"out", "outer", "over", "overlaps", "partition", "placing", "precision", "primary",
+	"procedure", "purge", "range", "rank", "read", "reads", "real", "recursive", "references",
+	"regexp", "release", "rename", "repeat", "replace", "require", "resignal", "restrict",
+	"return", "returning", "revoke", "right", "rlike", "row", "rows", "schema", "schemas", "select",
+	"separator", "session_user", "set", "show", "signal", "similar", "smallint", "some", "spatial",
+	"specific", "sql", "sqlstate", "starting", "stored", "straight_join", "symmetric", "system",
+	"table", "tablesample", "terminated", "then", "to", "trailing", "transaction", "trigger",
+	"true", "undo", "union", "unique", "unlock", "unsigned", "update", "usage", "use", "user",
+	"using", "values", "varchar", "variadic", "varying", "verbose", "virtual", "when", "where",
+	"while", "window", "with", "write", "xor", "zerofill",
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// IsReserved returns true if name is a keyword of any of the dialects, so that
+// it cannot be used as the name of a table or a column
+func IsReserved(name string) bool {
+	return reservedWords[strings.ToLower(name)]
+}
+
+// NewDialect returns the dialect with the given name, or the default one if
+// name is empty
+func NewDialect(name string) (Dialect, error) {
+	if len(name) == 0 {
+		return DefaultDialect, nil
+	}
+
+	d := Dialect(name)
+	if _, ok := dialectDrivers[d]; !ok {
+		return "", fmt.Errorf("Unsupported SQL dialect %q", name)
+	}
+	return d, nil
+}
+
+func (d Dialect) orDefault() Dialect {
+	if len(d) == 0 {
+		return DefaultDialect
+	}
+	return d
+}
+
+// String returns the name of the dialect
+func (d Dialect) String() string {
+	return string(d.orDefault())
+}
+
+// DriverImport returns the import path of the database driver
+func (d Dialect) DriverImport() string {
+	return dialectDrivers[d.orDefault()]
+}
+
+// Placeholder returns the placeholder for the n-th (starting by 1) parameter
+// of a query: "$1" or "?"
+func (d Dialect) Placeholder(n int) string {
+	if d.orDefault() == MySQL {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
+
+// ReturningID returns true if the id of an inserted row is got through a
+// RETURNING clause, as database driver does not support LastInsertId
+func (d Dialect) ReturningID() bool {
+	return d.orDefault() == Postgres
+}
+
+// NoLimit returns the LIMIT clause value meaning no limit, as some databases
+// do not support OFFSET without LIMIT
+func (d Dialect) NoLimit() string {
+	switch d.orDefault() {
+	case Postgres:
+		return "all"
+	case MySQL:
+		return "18446744073709551615"
+	default:
+		return "-1"
+	}
+}
+
+// ContainsFormat returns the format of the condition true when a string column,
+// the first verb, contains a text, the second verb. Text is matched case
+// sensitively and has no wildcards
+func (d Dialect) ContainsFormat() string {
+	switch d.orDefault() {
+	case Postgres:
+		return "strpos(%v, %v) > 0"
+	case MySQL:
+		return "instr(cast(%v as binary), %v) > 0"
+	default:
+		return "instr(%v, %v) > 0"
+	}
+}
+
+// SQLType returns the column type for a go type
+func (d Dialect) SQLType(t string) string {
+	sqlType := ddlType(t)
+
+	switch d.orDefault() {
+	case Postgres:
+		switch sqlType {
+		case "blob":
+			return "bytea"
+		}
+	case MySQL:
+		switch sqlType {
+		case "varchar":
+			return "varchar(255)"
+		case "real":
+			return "float"
+		case "double precision":
+			return "double"
+		case "timestamp":
+			return "datetime"
+		}
+	}
+
+	return sqlType
+}
+
+// IDColumnInDDL returns the definition of the primary key column for a go
+// type. Values of integer ones are generated by the database
+func (d Dialect) IDColumnInDDL(column, t string) string {
+	sqlType := d.SQLType(t)
+	if len(column) == 0 || len(sqlType) == 0 {
+		return ""
+	}
+
+	if sqlType != "integer" && sqlType != "bigint" {
+		return column + " " + sqlType + " primary key not null"
+	}
+
+	switch d.orDefault() {
+	case Postgres:
+		if sqlType == "bigint" {
+			return column + " bigserial primary key"
+		}
+		return column + " serial primary key"
+	case MySQL:
+		return column + " " + sqlType + " not null auto_increment primary key"
+	default:
+		// an integer primary key is an alias of the autoincremented rowid
+		return column + " integer primary key not null"
+	}
+}
+
+// UUIDColumnInDDL returns the definition of a primary key column holding uuids
+// in their canonical text form
+func (d Dialect) UUIDColumnInDDL(column string) string {
+	return column + " " + d.UUIDType() + " primary key not null"
+}
+
+// UUIDType returns the column type of uuids, used by uuid keys and by the
+// columns referencing them
+func (d Dialect) UUIDType() string {
+	switch d.orDefault() {
+	case Postgres:
+		return "uuid"
+	case MySQL:
+		return "char(36)"
+	default:
+		return "varchar(36)"
+	}
+}
@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type DialectSuite struct{}
+
+var _ = check.Suite(&DialectSuite{})
+
+func (s *DialectSuite) TestNewDialect(c *check.C) {
+	for _, name := range []string{"sqlite3", "postgres", "mysql"} {
+		d, err := NewDialect(name)
+		c.Assert(err, check.IsNil)
+		c.Assert(d.String(), check.Equals, name)
+	}
+}
+
+func (s *DialectSuite) TestNewDialect_empty(c *check.C) {
+	d, err := NewDialect("")
+	c.Assert(err, check.IsNil)
+	c.Assert(d, check.Equals, DefaultDialect)
+}
+
+func (s *DialectSuite) TestNewDialect_unsupported(c *check.C) {
+	_, err := NewDialect("oracle")
+	c.Assert(err, check.ErrorMatches, "Unsupported SQL dialect \"oracle\"")
+}
+
+func (s *DialectSuite) TestDriverImport(c *check.C) {
+	c.Assert(SQLite3.DriverImport(), check.Equals, "github.com/mattn/go-sqlite3")
+	c.Assert(Postgres.DriverImport(), check.Equals, "github.com/lib/pq")
+	c.Assert(MySQL.DriverImport(), check.Equals, "github.com/go-sql-driver/mysql")
+	c.Assert(Dialect("").DriverImport(), check.Equals, "github.com/mattn/go-sqlite3")
+}
+
+func (s *DialectSuite) TestPlaceholder(c *check.C) {
+	c.Assert(SQLite3.Placeholder(2), check.Equals, "$2")
+	c.Assert(Postgres.Placeholder(2), check.Equals, "$2")
+	c.Assert(MySQL.Placeholder(2), check.Equals, "?")
+}
+
+func (s *DialectSuite) TestReturningID(c *check.C) {
+	c.Assert(SQLite3.ReturningID(), check.Equals, false)
+	c.Assert(Postgres.ReturningID(), check.Equals, true)
+	c.Assert(MySQL.ReturningID(), check.Equals, false)
+}
+
+func (s *DialectSuite) TestNoLimit(c *check.C) {
+	c.Assert(Dialect("").NoLimit(), check.Equals, "-1")
+	c.Assert(Postgres.NoLimit(), check.Equals, "all")
+	c.Assert(MySQL.NoLimit(), check.Equals, "18446744073709551615")
+}
+
+func (s *DialectSuite) TestContainsFormat(c *check.C) {
+	c.Assert(Dialect("").ContainsFormat(), check.Equals, "instr(%v, %v) > 0")
+	c.Assert(Postgres.ContainsFormat(), check.Equals, "strpos(%v, %v) > 0")
+	c.Assert(MySQL.ContainsFormat(), check.Equals, "instr(cast(%v as binary), %v) > 0")
+}
+
+func (s *DialectSuite) TestIsReserved(c *check.C) {
+	c.Assert(IsReserved("order"), check.Equals, true)
+	c.Assert(IsReserved("Select"), check.Equals, true)
+	c.Assert(IsReserved("user"), check.Equals, true)
+	c.Assert(IsReserved("key"), check.Equals, true)
+	c.Assert(IsReserved("orders"), check.Equals, false)
+	c.Assert(IsReserved("name"), check.Equals, false)
+}
+
+func (s *DialectSuite) TestSQLType(c *check.C) {
+	c.Assert(SQLite3.SQLType("string"), check.Equals, "varchar")
+	c.Assert(SQLite3.SQLType("[]byte"), check.Equals, "blob")
+
+	c.Assert(Postgres.SQLType("string"), check.Equals, "varchar")
+	c.Assert(Postgres.SQLType("[]byte"), check.Equals, "bytea")
+	c.Assert(Postgres.SQLType("[]string"), check.Equals, "text")
+	c.Assert(Postgres.SQLType("time.Time"), check.Equals, "timestamp")
+
+	c.Assert(MySQL.SQLType("string"), check.Equals, "varchar(255)")
+	c.Assert(MySQL.SQLType("*string"), check.Equals, "varchar(255)")
+	c.Assert(MySQL.SQLType("float32"), check.Equals, "float")
+	c.Assert(MySQL.SQLType("float64"), check.Equals, "double")
+	c.Assert(MySQL.SQLType("time.Time"), check.Equals, "datetime")
+	c.Assert(MySQL.SQLType("other"), check.Equals, "")
+}
+
+func (s *DialectSuite) TestIDColumnInDDL(c *check.C) {
+	c.Assert(SQLite3.IDColumnInDDL("id", "int"), check.Equals, "id integer primary key not null")
+	c.Assert(SQLite3.IDColumnInDDL("id", "int64"), check.Equals, "id integer primary key not null")
+	c.Assert(Postgres.IDColumnInDDL("id", "int"), check.Equals, "id serial primary key")
+	c.Assert(Postgres.IDColumnInDDL("id", "int64"), check.Equals, "id bigserial primary key")
+	c.Assert(MySQL.IDColumnInDDL("id", "int"), check.Equals, "id integer not null auto_increment primary key")
+
+	c.Assert(SQLite3.IDColumnInDDL("code", "string"), check.Equals, "code varchar primary key not null")
+	c.Assert(MySQL.IDColumnInDDL("code", "string"), check.Equals, "code varchar(255) primary key not null")
+
+	c.Assert(SQLite3.IDColumnInDDL("", "int"), check.Equals, "")
+	c.Assert(SQLite3.IDColumnInDDL("id", "other"), check.Equals, "")
+}
@@ -0,0 +1,147 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// Directives are set in the doc comment of a type, like //cruder:skip
+const (
+	directivePrefix = "//cruder:"
+
+	// skipDirective excludes the type from generation
+	skipDirective = "skip"
+	// resourceDirective marks the type for generation. When any type is marked,
+	// the ones not marked are excluded
+	resourceDirective = "resource"
+	// operationsDirective restricts the operations of the type to the ones
+	// given, like //cruder:operations list,get,create
+	operationsDirective = "operations"
+	// readOnlyDirective restricts the operations of the type to list and get
+	readOnlyDirective = "readonly"
+	// auditDirective makes the type table keep the creation, update and
+	// deletion times of its registers, which are deleted softly
+	auditDirective = "audit"
+)
+
+// knownDirectives tells for every directive whether it takes arguments
+var knownDirectives = map[string]bool{
+	skipDirective:       false,
+	resourceDirective:   false,
+	operationsDirective: true,
+	readOnlyDirective:   false,
+	auditDirective:      false,
+}
+
+// typeDirectives returns the cruder directives in the doc comment of a type
+// declaration, with their arguments by directive name
+func typeDirectives(decl *ast.GenDecl, spec *ast.TypeSpec) (map[string]string, error) {
+	docs := []*ast.CommentGroup{spec.Doc}
+	// the doc of a grouped declaration belongs to the group, not to its types
+	if !decl.Lparen.IsValid() {
+		docs = append(docs, decl.Doc)
+	}
+
+	directives := make(map[string]string)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		for _, comment := range doc.List {
+			if !strings.HasPrefix(comment.Text, directivePrefix) {
+				continue
+			}
+
+			name := strings.TrimPrefix(comment.Text, directivePrefix)
+			args := ""
+			if i := strings.IndexAny(name, " \t"); i >= 0 {
+				name, args = name[:i], strings.TrimSpace(name[i:])
+			}
+
+			takesArgs, ok := knownDirectives[name]
+			if !ok {
+				return nil, fmt.Errorf("Unknown directive %v%v in doc comment of type %v", directivePrefix, name, spec.Name.Name)
+			}
+			if takesArgs && len(args) == 0 {
+				return nil, fmt.Errorf("Directive %v%v of type %v needs arguments", directivePrefix, name, spec.Name.Name)
+			}
+			if !takesArgs && len(args) > 0 {
+				return nil, fmt.Errorf("Directive %v%v of type %v does not take arguments", directivePrefix, name, spec.Name.Name)
+			}
+			directives[name] = args
+		}
+	}
+
+	_, skip := directives[skipDirective]
+	_, resource := directives[resourceDirective]
+	if skip && resource {
+		return nil, fmt.Errorf("Type %v cannot be skipped and marked as resource at the same time", spec.Name.Name)
+	}
+
+	return directives, nil
+}
+
+// getTypeDirectives returns the directives of the struct types declared in
+// decls, by type name
+func getTypeDirectives(decls []*ast.GenDecl) (map[string]map[string]string, error) {
+	directives := make(map[string]map[string]string)
+	for _, decl := range decls {
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+				continue
+			}
+
+			d, err := typeDirectives(decl, typeSpec)
+			if err != nil {
+				return nil, err
+			}
+			directives[typeSpec.Name.Name] = d
+		}
+	}
+	return directives, nil
+}
+
+// StripDirectives returns the doc comment without its cruder directives, nor
+// the empty lines left at its end, or nil if nothing else remains
+func StripDirectives(doc *ast.CommentGroup) *ast.CommentGroup {
+	if doc == nil {
+		return nil
+	}
+
+	comments := []*ast.Comment{}
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, directivePrefix) {
+			comments = append(comments, comment)
+		}
+	}
+	for len(comments) > 0 && strings.TrimSpace(comments[len(comments)-1].Text) == "//" {
+		comments = comments[:len(comments)-1]
+	}
+
+	if len(comments) == 0 {
+		return nil
+	}
+	return &ast.CommentGroup{List: comments}
+}
@@ -0,0 +1,164 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"go/ast"
+
+	"github.com/rmescandon/cruder/io"
+
+	check "gopkg.in/check.v1"
+)
+
+type DirectiveSuite struct{}
+
+var _ = check.Suite(&DirectiveSuite{})
+
+func holderNames(holders []*TypeHolder) []string {
+	names := []string{}
+	for _, h := range holders {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func (s *DirectiveSuite) TestComposeTypeHolders_skip(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	type Book struct {
+		ID    int
+		Title string
+	}
+
+	// BookSummary is returned by a hand made endpoint
+	//cruder:skip
+	type BookSummary struct {
+		Title string
+	}
+
+	type (
+		//cruder:skip
+		Pagination struct {
+			Page int
+		}
+
+		Author struct {
+			ID   int
+			Name string
+		}
+	)
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(holderNames(th), check.DeepEquals, []string{"Book", "Author"})
+}
+
+func (s *DirectiveSuite) TestComposeTypeHolders_resource(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	//cruder:resource
+	type Book struct {
+		ID    int
+		Title string
+	}
+
+	type BookSummary struct {
+		Title string
+	}
+	`, `
+	package model
+
+	// Author of books
+	//cruder:resource
+	type Author struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(holderNames(th), check.DeepEquals, []string{"Book", "Author"})
+}
+
+func (s *DirectiveSuite) TestComposeTypeHolders_groupDocIgnored(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	//cruder:skip
+	type (
+		Book struct {
+			ID    int
+			Title string
+		}
+	)
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(holderNames(th), check.DeepEquals, []string{"Book"})
+}
+
+func (s *DirectiveSuite) TestComposeTypeHolders_invalidDirectives(c *check.C) {
+	for _, t := range []struct {
+		doc string
+		err string
+	}{
+		{"//cruder:whatever", "Unknown directive //cruder:whatever in doc comment of type Book"},
+		{"//cruder:skip please", "Directive //cruder:skip of type Book does not take arguments"},
+		{"//cruder:skip\n\t//cruder:resource", "Type Book cannot be skipped and marked as resource at the same time"},
+	} {
+		_, err := composeTestTypeHolders(c, `
+	package model
+
+	`+t.doc+`
+	type Book struct {
+		ID    int
+		Title string
+	}
+	`)
+		c.Assert(err, check.ErrorMatches, t.err)
+	}
+}
+
+func (s *DirectiveSuite) TestStripDirectives(c *check.C) {
+	content, err := io.NewContent(`
+	package model
+
+	// Invoice is a bill
+	//
+	//cruder:audit
+	//cruder:operations list,get
+	type Invoice struct {
+		ID int
+	}
+
+	//cruder:readonly
+	type Country struct {
+		ID int
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	doc := StripDirectives(content.Ast.Decls[0].(*ast.GenDecl).Doc)
+	c.Assert(doc, check.NotNil)
+	c.Assert(doc.Text(), check.Equals, "Invoice is a bill\n")
+	c.Assert(doc.List, check.HasLen, 1)
+
+	c.Assert(StripDirectives(content.Ast.Decls[1].(*ast.GenDecl).Doc), check.IsNil)
+	c.Assert(StripDirectives(nil), check.IsNil)
+}
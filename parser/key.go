@@ -0,0 +1,295 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// keyPattern is the pattern string keys supplied by clients must match, so
+// that they can be used in REST paths as they are
+const keyPattern = "^[a-zA-Z0-9_.-]+$"
+
+// uuidPattern is the pattern of generated uuid keys in REST paths
+const uuidPattern = "[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}"
+
+// KeyFields returns the fields making up the primary key of the type: the ones
+// tagged as id or, if none is, the first field
+func (holder *TypeHolder) KeyFields() []TypeField {
+	fields := []TypeField{}
+	for _, field := range holder.Fields {
+		if field.Tags.ID {
+			fields = append(fields, field)
+		}
+	}
+
+	if f := holder.idField(); len(fields) == 0 && f != nil {
+		fields = append(fields, *f)
+	}
+	return fields
+}
+
+// IsKey returns true if the field with the given name is part of the key
+func (holder *TypeHolder) IsKey(name string) bool {
+	for _, field := range holder.KeyFields() {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CompositeKey returns true if the key is made of several fields
+func (holder *TypeHolder) CompositeKey() bool {
+	return len(holder.KeyFields()) > 1
+}
+
+// SerialKey returns true if the key is a single field whose values are
+// generated by the database, like integer ones
+func (holder *TypeHolder) SerialKey() bool {
+	return len(holder.KeyFields()) == 1 && !holder.UUIDKey() && !holder.ClientKey()
+}
+
+// UUIDKey returns true if the key is a single string field tagged as id=uuid,
+// whose values are generated when registers are created
+func (holder *TypeHolder) UUIDKey() bool {
+	fields := holder.KeyFields()
+	return len(fields) == 1 && fields[0].Tags.UUID
+}
+
+// ClientKey returns true if the values of the key are supplied by clients when
+// creating registers, as it happens with string and composite keys
+func (holder *TypeHolder) ClientKey() bool {
+	fields := holder.KeyFields()
+	switch len(fields) {
+	case 0:
+		return false
+	case 1:
+		return fields[0].Type == "string" && !fields[0].Tags.UUID
+	default:
+		return true
+	}
+}
+
+// StringKey returns true if all the fields of the key are strings, so that
+// they need no parsing nor formatting in REST paths
+func (holder *TypeHolder) StringKey() bool {
+	for _, field := range holder.KeyFields() {
+		if !field.IsString() {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyParams returns the key fields declared as function parameters, named as
+// their route variables: "bookid int, tagid string"
+func (holder *TypeHolder) KeyParams() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, field.RouteVar()+" "+field.Type)
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyVars returns the names of the variables holding the key: "bookid, tagid"
+func (holder *TypeHolder) KeyVars() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, field.RouteVar())
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyTypes returns the types of the key fields: "int, string"
+func (holder *TypeHolder) KeyTypes() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, field.Type)
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyValues returns the key fields of the struct held by v: "v.BookID, v.TagID"
+func (holder *TypeHolder) KeyValues(v string) string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, v+"."+field.Name)
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyZeroValues returns the zero values of the key fields: `0, ""`
+func (holder *TypeHolder) KeyZeroValues() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		switch {
+		case field.Type == "string":
+			tokens = append(tokens, `""`)
+		case field.ValueKind() == "int":
+			tokens = append(tokens, "0")
+		default:
+			tokens = append(tokens, "*new("+field.Type+")")
+		}
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyColumns returns the database columns of the key: "book_id, tag_id"
+func (holder *TypeHolder) KeyColumns() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, field.ColumnName())
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// KeyCondition returns the condition selecting a register by its key, with
+// parameters numbered from 1: "book_id=$1 and tag_id=$2"
+func (holder *TypeHolder) KeyCondition() string {
+	return holder.keyCondition(1)
+}
+
+func (holder *TypeHolder) keyCondition(first int) string {
+	tokens := []string{}
+	for i, field := range holder.KeyFields() {
+		tokens = append(tokens, field.ColumnName()+"="+holder.Dialect.Placeholder(first+i))
+	}
+	return strings.Join(tokens, " and ")
+}
+
+// KeyPath returns the REST path elements of the key, as route variables with
+// their patterns: "{bookid:[0-9]+}/{tagid:[a-zA-Z0-9_.-]+}"
+func (holder *TypeHolder) KeyPath() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		pattern := field.RouteVarPattern()
+		if field.Tags.UUID {
+			pattern = uuidPattern
+		}
+		tokens = append(tokens, "{"+field.RouteVar()+":"+pattern+"}")
+	}
+	return strings.Join(tokens, "/")
+}
+
+// KeyFormat returns the instruction formatting the key variables as the REST
+// path elements of a register: strconv.Itoa(bookid) + "/" + tagid
+func (holder *TypeHolder) KeyFormat() string {
+	tokens := []string{}
+	for _, field := range holder.KeyFields() {
+		tokens = append(tokens, typeFormat(field.Type, field.RouteVar()))
+	}
+	return strings.Join(tokens, ` + "/" + `)
+}
+
+// InsertColumns returns the columns set when inserting a register. The key is
+// left out when the database generates it
+func (holder *TypeHolder) InsertColumns() string {
+	columns := holder.KeyColumns() + ", " + holder.FieldsInDML()
+	if holder.SerialKey() {
+		columns = holder.FieldsInDML()
+	}
+	if holder.Audit {
+		columns += ", " + CreatedAtColumn + ", " + UpdatedAtColumn
+	}
+	return columns
+}
+
+// InsertParams returns the placeholders of InsertColumns: "$1, $2, $3". Audit
+// times are set to the current one
+func (holder *TypeHolder) InsertParams() string {
+	count := len(holder.Fields) - len(holder.KeyFields())
+	if !holder.SerialKey() {
+		count = len(holder.Fields)
+	}
+
+	tokens := []string{}
+	for i := 1; i <= count; i++ {
+		tokens = append(tokens, holder.Dialect.Placeholder(i))
+	}
+	if holder.Audit {
+		tokens = append(tokens, "current_timestamp", "current_timestamp")
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// InsertValues returns the values of InsertColumns, held by the type identifier:
+// "theType.BookID, theType.TagID, theType.Field1"
+func (holder *TypeHolder) InsertValues() string {
+	return holder.InsertValuesIn("")
+}
+
+// InsertValuesIn returns InsertValues as used out of datastore package, from
+// the package named pkg, like "datastore"
+func (holder *TypeHolder) InsertValuesIn(pkg string) string {
+	if len(pkg) > 0 {
+		pkg += "."
+	}
+
+	fields := holder.fieldsEnum(false, pkg)
+	if holder.SerialKey() {
+		return fields
+	}
+	return holder.KeyValues(holder.Identifier()) + ", " + fields
+}
+
+// keyInDDL returns the definition of the column of a field in a composite key
+func (holder *TypeHolder) keyInDDL(field TypeField) string {
+	return field.ColumnName() + " " + holder.fieldSQLType(field) + " not null"
+}
+
+// IsString returns true if the field holds a string, or a pointer to it
+func (f *TypeField) IsString() bool {
+	return strings.TrimPrefix(f.Type, "*") == "string"
+}
+
+// KeySampleValue returns a Go expression evaluating to a sample value for the
+// field as part of a key supplied by clients, which can be used in REST paths
+func (f *TypeField) KeySampleValue() string {
+	if f.Type != "string" || len(f.sampleString()) > 0 {
+		return f.SampleValue()
+	}
+	return fmt.Sprintf("fmt.Sprintf(\"%v-%%d\", n)", strings.ToLower(f.Name))
+}
+
+// KeyValidationCode returns the statements checking the value of the field, as
+// part of a key supplied by clients, held by the struct v. String keys are
+// required and must be usable in REST paths as they are
+func (f *TypeField) KeyValidationCode(v string) string {
+	if f.Type != "string" {
+		return f.ValidationCode(v)
+	}
+
+	value := v + "." + f.Name
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\tif %v == \"\" {\n", value)
+	fmt.Fprintf(&buf, "\t\tfieldErrors = append(fieldErrors, FieldError{Field: %q, Message: \"is required\"})\n", f.JSONName())
+	fmt.Fprintf(&buf, "\t} else if !matchesPattern(%v, %q) {\n", value, keyPattern)
+	fmt.Fprintf(&buf, "\t\tfieldErrors = append(fieldErrors, FieldError{Field: %q, Message: %q})\n", f.JSONName(),
+		"must only hold letters, digits, _, . and -")
+	buf.WriteString("\t}\n")
+
+	required := *f
+	required.Tags.Required = false
+	buf.WriteString(required.ValidationCode(v))
+	return buf.String()
+}
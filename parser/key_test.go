@@ -0,0 +1,158 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"strings"
+
+	check "gopkg.in/check.v1"
+)
+
+type KeySuite struct {
+	serial    TypeHolder
+	uuid      TypeHolder
+	slug      TypeHolder
+	composite TypeHolder
+}
+
+var _ = check.Suite(&KeySuite{})
+
+func (s *KeySuite) SetUpTest(c *check.C) {
+	s.serial = TypeHolder{
+		Name: "Book",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int64"},
+			{Name: "Title", Type: "string"},
+		},
+	}
+	s.uuid = TypeHolder{
+		Name: "Book",
+		Fields: []TypeField{
+			{Name: "ID", Type: "string", Tags: FieldTags{ID: true, UUID: true}},
+			{Name: "Title", Type: "string"},
+		},
+	}
+	s.slug = TypeHolder{
+		Name: "Tag",
+		Fields: []TypeField{
+			{Name: "Name", Type: "string"},
+			{Name: "Description", Type: "string"},
+		},
+	}
+	s.composite = TypeHolder{
+		Name: "BookTag",
+		Fields: []TypeField{
+			{Name: "BookID", Type: "int", Tags: FieldTags{ID: true, Column: "book_id"}},
+			{Name: "TagName", Type: "string", Tags: FieldTags{ID: true, Column: "tag_name"}},
+			{Name: "Weight", Type: "int"},
+			{Name: "Note", Type: "string"},
+		},
+	}
+}
+
+func (s *KeySuite) TestKinds(c *check.C) {
+	c.Assert(s.serial.SerialKey(), check.Equals, true)
+	c.Assert(s.serial.ClientKey(), check.Equals, false)
+	c.Assert(s.serial.StringKey(), check.Equals, false)
+
+	c.Assert(s.uuid.UUIDKey(), check.Equals, true)
+	c.Assert(s.uuid.SerialKey(), check.Equals, false)
+	c.Assert(s.uuid.ClientKey(), check.Equals, false)
+	c.Assert(s.uuid.StringKey(), check.Equals, true)
+
+	c.Assert(s.slug.ClientKey(), check.Equals, true)
+	c.Assert(s.slug.CompositeKey(), check.Equals, false)
+
+	c.Assert(s.composite.CompositeKey(), check.Equals, true)
+	c.Assert(s.composite.ClientKey(), check.Equals, true)
+	c.Assert(s.composite.IsKey("TagName"), check.Equals, true)
+	c.Assert(s.composite.IsKey("Weight"), check.Equals, false)
+
+	empty := TypeHolder{}
+	c.Assert(empty.KeyFields(), check.HasLen, 0)
+	c.Assert(empty.ClientKey(), check.Equals, false)
+}
+
+func (s *KeySuite) TestKeyCode(c *check.C) {
+	c.Assert(s.composite.KeyParams(), check.Equals, "bookid int, tagname string")
+	c.Assert(s.composite.KeyVars(), check.Equals, "bookid, tagname")
+	c.Assert(s.composite.KeyTypes(), check.Equals, "int, string")
+	c.Assert(s.composite.KeyValues("v"), check.Equals, "v.BookID, v.TagName")
+	c.Assert(s.composite.KeyColumns(), check.Equals, "book_id, tag_name")
+	c.Assert(s.composite.KeyCondition(), check.Equals, "book_id=$1 and tag_name=$2")
+	c.Assert(s.composite.KeyPath(), check.Equals, "{bookid:[0-9]+}/{tagname:[a-zA-Z0-9_.-]+}")
+	c.Assert(s.composite.KeyFormat(), check.Equals, `strconv.Itoa(bookid) + "/" + tagname`)
+
+	c.Assert(s.serial.KeyFormat(), check.Equals, "strconv.FormatInt(id, 10)")
+	c.Assert(s.uuid.KeyPath(), check.Equals, "{id:"+uuidPattern+"}")
+	c.Assert(s.uuid.IDFieldPattern(), check.Equals, uuidPattern)
+}
+
+func (s *KeySuite) TestDML(c *check.C) {
+	c.Assert(s.composite.FieldsInDML(), check.Equals, "weight, note")
+	c.Assert(s.composite.FieldsEnum(), check.Equals, "bookTag.Weight, bookTag.Note")
+	c.Assert(s.composite.FieldsAsDMLParams(), check.Equals, "weight=$1, note=$2")
+	c.Assert(s.composite.IDFieldAsDMLParam(), check.Equals, "book_id=$3 and tag_name=$4")
+	c.Assert(s.composite.InsertColumns(), check.Equals, "book_id, tag_name, weight, note")
+	c.Assert(s.composite.InsertParams(), check.Equals, "$1, $2, $3, $4")
+	c.Assert(s.composite.InsertValues(), check.Equals, "bookTag.BookID, bookTag.TagName, bookTag.Weight, bookTag.Note")
+
+	c.Assert(s.serial.InsertColumns(), check.Equals, "title")
+	c.Assert(s.serial.InsertParams(), check.Equals, "$1")
+	c.Assert(s.serial.InsertValues(), check.Equals, "book.Title")
+
+	s.uuid.Dialect = MySQL
+	c.Assert(s.uuid.InsertColumns(), check.Equals, "id, title")
+	c.Assert(s.uuid.InsertParams(), check.Equals, "?, ?")
+}
+
+func (s *KeySuite) TestColumns(c *check.C) {
+	c.Assert(s.composite.Columns(), check.DeepEquals, []Column{
+		{Name: "book_id", Definition: "book_id integer not null", PrimaryKey: true},
+		{Name: "tag_name", Definition: "tag_name varchar not null", PrimaryKey: true},
+		{Name: "weight", Definition: "weight integer"},
+		{Name: "note", Definition: "note varchar"},
+		{Name: "row_version", Definition: "row_version integer not null default 1"},
+	})
+
+	c.Assert(s.uuid.Columns()[0].Definition, check.Equals, "id varchar(36) primary key not null")
+	s.uuid.Dialect = Postgres
+	c.Assert(s.uuid.Columns()[0].Definition, check.Equals, "id uuid primary key not null")
+	s.uuid.Dialect = MySQL
+	c.Assert(s.uuid.Columns()[0].Definition, check.Equals, "id char(36) primary key not null")
+
+	c.Assert(s.slug.Columns()[0].Definition, check.Equals, "name varchar primary key not null")
+}
+
+func (s *KeySuite) TestKeySampleValue(c *check.C) {
+	c.Assert(s.composite.Fields[0].KeySampleValue(), check.Equals, "int(n)")
+	c.Assert(s.composite.Fields[1].KeySampleValue(), check.Equals, `fmt.Sprintf("tagname-%d", n)`)
+
+	enum := TypeField{Name: "Kind", Type: "string", Tags: FieldTags{Enum: []string{"a", "b"}}}
+	c.Assert(enum.KeySampleValue(), check.Equals, enum.SampleValue())
+}
+
+func (s *KeySuite) TestKeyValidationCode(c *check.C) {
+	code := s.composite.Fields[1].KeyValidationCode("v")
+	c.Assert(strings.Contains(code, `if v.TagName == "" {`), check.Equals, true)
+	c.Assert(strings.Contains(code, `} else if !matchesPattern(v.TagName, "^[a-zA-Z0-9_.-]+$") {`), check.Equals, true)
+
+	c.Assert(s.composite.Fields[0].KeyValidationCode("v"), check.Equals, "")
+}
@@ -0,0 +1,182 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// Operations of the REST resource generated for a type
+const (
+	ListOperation   = "list"
+	GetOperation    = "get"
+	CreateOperation = "create"
+	UpdateOperation = "update"
+	PatchOperation  = "patch"
+	DeleteOperation = "delete"
+	BatchOperation  = "batch"
+)
+
+// Operations lists all the operations a type can support
+var Operations = []string{ListOperation, GetOperation, CreateOperation, UpdateOperation, PatchOperation, DeleteOperation, BatchOperation}
+
+// batchActions are the operations that can be part of a batch
+var batchActions = []string{CreateOperation, UpdateOperation, DeleteOperation}
+
+// typeOperations returns the operations a type is restricted to by its
+// directives, or nil if it supports all of them
+func typeOperations(name string, directives map[string]string) ([]string, error) {
+	args, restricted := directives[operationsDirective]
+	_, readOnly := directives[readOnlyDirective]
+
+	switch {
+	case restricted && readOnly:
+		return nil, fmt.Errorf("Type %v cannot be read only and have its operations set at the same time", name)
+	case readOnly:
+		return []string{ListOperation, GetOperation}, nil
+	case !restricted:
+		return nil, nil
+	}
+
+	var operations []string
+	for _, op := range strings.Split(args, ",") {
+		op = strings.TrimSpace(op)
+		if !isOperation(op) {
+			return nil, fmt.Errorf("Unknown operation %q for type %v. Valid ones are %v", op, name, strings.Join(Operations, ", "))
+		}
+		operations = append(operations, op)
+	}
+
+	holder := TypeHolder{Operations: operations}
+	if holder.Supports(BatchOperation) && len(holder.BatchActions()) == 0 {
+		return nil, fmt.Errorf("Operation batch of type %v needs any of %v ones", name, strings.Join(batchActions, ", "))
+	}
+	return operations, nil
+}
+
+func isOperation(operation string) bool {
+	for _, op := range Operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports returns true if the type supports any of the operations. All of
+// them are supported when the type operations are not restricted
+func (holder *TypeHolder) Supports(operations ...string) bool {
+	for _, op := range operations {
+		if len(holder.Operations) == 0 {
+			return true
+		}
+		for _, supported := range holder.Operations {
+			if op == supported {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BatchActions returns the operations of the type that can be part of a batch,
+// or none if it does not support batches
+func (holder *TypeHolder) BatchActions() []string {
+	actions := []string{}
+	if !holder.Supports(BatchOperation) {
+		return actions
+	}
+	for _, action := range batchActions {
+		if holder.Supports(action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// SupportedFuncNames returns the names of the handler and datastore functions
+// of the operations the type supports, like GetBook
+func (holder *TypeHolder) SupportedFuncNames() []string {
+	names := []string{}
+	for _, op := range Operations {
+		if holder.Supports(op) {
+			names = append(names, holder.operationFuncNames(op)...)
+		}
+	}
+	return names
+}
+
+// UnsupportedFuncNames returns the names of the handler and datastore functions
+// of the operations the type does not support, like DeleteBook
+func (holder *TypeHolder) UnsupportedFuncNames() []string {
+	names := []string{}
+	for _, op := range Operations {
+		if !holder.Supports(op) {
+			names = append(names, holder.operationFuncNames(op)...)
+		}
+	}
+	return names
+}
+
+// UnsupportedNames returns the names of the functions, mock fields and types
+// generated only for the operations the type does not support, which outputs
+// generated once may still use, like DeleteBook, DeleteBookFunc or BookOperation
+func (holder *TypeHolder) UnsupportedNames() []string {
+	names := []string{}
+	for _, name := range holder.UnsupportedFuncNames() {
+		names = append(names, name, name+"Func")
+	}
+	if !holder.Supports(PatchOperation) {
+		names = append(names, holder.Name+"Columns")
+	}
+	if !holder.Supports(BatchOperation) {
+		names = append(names, holder.Name+"Operation")
+	}
+	return names
+}
+
+// StaleNames returns the UnsupportedNames used in file, an output generated
+// before the type stopped supporting their operations
+func (holder *TypeHolder) StaleNames(file *ast.File) []string {
+	if holder == nil || file == nil {
+		return nil
+	}
+	return UsedNames(file, holder.UnsupportedNames())
+}
+
+// operationFuncNames returns the names of the handler and datastore functions
+// implementing an operation for the type
+func (holder *TypeHolder) operationFuncNames(operation string) []string {
+	switch operation {
+	case BatchOperation:
+		return []string{"Batch" + holder.Name + "s"}
+	case ListOperation:
+	default:
+		return []string{strings.Title(operation) + holder.Name}
+	}
+
+	names := []string{"List" + holder.Name + "s"}
+	for _, field := range holder.References() {
+		names = append(names, "List"+field.Ref.Type+holder.Name+"s")
+	}
+	return names
+}
@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"github.com/rmescandon/cruder/io"
+
+	check "gopkg.in/check.v1"
+)
+
+type OperationSuite struct{}
+
+var _ = check.Suite(&OperationSuite{})
+
+func (s *OperationSuite) TestComposeTypeHolders_operations(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package model
+
+	type Book struct {
+		ID    int
+		Title string
+	}
+
+	//cruder:readonly
+	type Author struct {
+		ID   int
+		Name string
+	}
+
+	// Tag can be created and deleted only
+	//cruder:operations create, delete
+	type Tag struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 3)
+	c.Assert(th[0].Operations, check.IsNil)
+	c.Assert(th[1].Operations, check.DeepEquals, []string{"list", "get"})
+	c.Assert(th[2].Operations, check.DeepEquals, []string{"create", "delete"})
+}
+
+func (s *OperationSuite) TestComposeTypeHolders_invalidOperations(c *check.C) {
+	for _, t := range []struct {
+		doc string
+		err string
+	}{
+		{"//cruder:operations", "Directive //cruder:operations of type Book needs arguments"},
+		{"//cruder:operations list,remove", `Unknown operation "remove" for type Book. Valid ones are list, get, create, update, patch, delete, batch`},
+		{"//cruder:operations list,", `Unknown operation "" for type Book. .*`},
+		{"//cruder:readonly\n\t//cruder:operations list", "Type Book cannot be read only and have its operations set at the same time"},
+		{"//cruder:operations list,batch", "Operation batch of type Book needs any of create, update, delete ones"},
+	} {
+		_, err := composeTestTypeHolders(c, `
+	package model
+
+	`+t.doc+`
+	type Book struct {
+		ID    int
+		Title string
+	}
+	`)
+		c.Assert(err, check.ErrorMatches, t.err)
+	}
+}
+
+func (s *OperationSuite) TestSupports(c *check.C) {
+	holder := &TypeHolder{Name: "Book"}
+	c.Assert(holder.Supports("create"), check.Equals, true)
+	c.Assert(holder.Supports(), check.Equals, false)
+
+	holder.Operations = []string{"list", "get"}
+	c.Assert(holder.Supports("get"), check.Equals, true)
+	c.Assert(holder.Supports("create"), check.Equals, false)
+	c.Assert(holder.Supports("create", "list"), check.Equals, true)
+}
+
+func (s *OperationSuite) TestUnsupportedFuncNames(c *check.C) {
+	holder := &TypeHolder{
+		Name: "Book",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "AuthorID", Type: "int", Ref: &Reference{Type: "Author", Table: "author", Column: "id"}},
+		},
+	}
+	c.Assert(holder.UnsupportedFuncNames(), check.HasLen, 0)
+
+	holder.Operations = []string{"get", "update"}
+	c.Assert(holder.UnsupportedFuncNames(), check.DeepEquals,
+		[]string{"ListBooks", "ListAuthorBooks", "CreateBook", "PatchBook", "DeleteBook", "BatchBooks"})
+}
+
+func (s *OperationSuite) TestSupportedFuncNames(c *check.C) {
+	holder := &TypeHolder{
+		Name: "Book",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "AuthorID", Type: "int", Ref: &Reference{Type: "Author", Table: "author", Column: "id"}},
+		},
+	}
+	c.Assert(holder.SupportedFuncNames(), check.DeepEquals, []string{"ListBooks", "ListAuthorBooks", "GetBook",
+		"CreateBook", "UpdateBook", "PatchBook", "DeleteBook", "BatchBooks"})
+
+	holder.Operations = []string{"get", "update"}
+	c.Assert(holder.SupportedFuncNames(), check.DeepEquals, []string{"GetBook", "UpdateBook"})
+}
+
+func (s *OperationSuite) TestUnsupportedNames(c *check.C) {
+	holder := &TypeHolder{Name: "Book"}
+	c.Assert(holder.UnsupportedNames(), check.HasLen, 0)
+
+	holder.Operations = []string{"list", "get", "update", "patch", "delete"}
+	c.Assert(holder.UnsupportedNames(), check.DeepEquals,
+		[]string{"CreateBook", "CreateBookFunc", "BatchBooks", "BatchBooksFunc", "BookOperation"})
+}
+
+func (s *OperationSuite) TestStaleNames(c *check.C) {
+	content, err := io.NewContent(`package handler
+
+	// DeleteBook handles deleting a book
+	func DeleteBook(w http.ResponseWriter, r *http.Request) {
+		err := Store.DeleteBook(r.Context(), 1, 1)
+		ops := []datastore.BookOperation{}
+	}
+	`)
+	c.Assert(err, check.IsNil)
+
+	holder := &TypeHolder{Name: "Book"}
+	c.Assert(holder.StaleNames(content.Ast), check.HasLen, 0)
+
+	holder.Operations = []string{"list", "get"}
+	c.Assert(holder.StaleNames(content.Ast), check.DeepEquals, []string{"DeleteBook", "BookOperation"})
+}
+
+func (s *OperationSuite) TestBatchActions(c *check.C) {
+	holder := &TypeHolder{Name: "Book"}
+	c.Assert(holder.BatchActions(), check.DeepEquals, []string{"create", "update", "delete"})
+
+	holder.Operations = []string{"get", "delete", "batch"}
+	c.Assert(holder.BatchActions(), check.DeepEquals, []string{"delete"})
+
+	holder.Operations = []string{"create", "delete"}
+	c.Assert(holder.BatchActions(), check.HasLen, 0)
+}
@@ -0,0 +1,178 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference is the relation of a field holding the id of a register of other
+// type, or of the same one, with the table of that type
+type Reference struct {
+	Type   string
+	Table  string
+	Column string
+	// UUID is set when the referenced key holds generated uuids, so that the
+	// referencing column gets their type
+	UUID bool
+}
+
+// RouteVar returns the name of the path variable holding the referenced id in
+// nested routes
+func (f *TypeField) RouteVar() string {
+	return strings.ToLower(f.Name)
+}
+
+// RouteVarParse returns the instruction parsing the referenced id from the
+// path variables of a nested route, held in a vars map
+func (f *TypeField) RouteVarParse() string {
+	return typeParse(strings.TrimPrefix(f.Type, "*"), "vars[\""+f.RouteVar()+"\"]")
+}
+
+// RouteVarPattern returns the pattern of the referenced id in nested routes
+func (f *TypeField) RouteVarPattern() string {
+	if f.Ref != nil && f.Ref.UUID {
+		return uuidPattern
+	}
+	return typePattern(strings.TrimPrefix(f.Type, "*"))
+}
+
+// RouteVarFormat returns the instruction formatting the referenced id held by
+// the field of the struct v as a string, to compose nested routes
+func (f *TypeField) RouteVarFormat(v string) string {
+	value := v + "." + f.Name
+	if strings.HasPrefix(f.Type, "*") {
+		value = "*" + value
+	}
+	return typeFormat(strings.TrimPrefix(f.Type, "*"), value)
+}
+
+// RouteVarOpenAPISchema returns the OpenAPI schema of the referenced id in
+// nested routes
+func (f *TypeField) RouteVarOpenAPISchema() string {
+	return openAPISchema(strings.TrimPrefix(f.Type, "*"), nil)
+}
+
+// References returns the fields referencing other types, or the same one. If
+// several reference the same type, only the first one is returned
+func (holder *TypeHolder) References() []TypeField {
+	fields := []TypeField{}
+	referenced := make(map[string]bool)
+	for _, field := range holder.Fields {
+		if field.Ref == nil || referenced[field.Ref.Type] {
+			continue
+		}
+		referenced[field.Ref.Type] = true
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// resolveReferences sets the references of the fields holding the ids of other
+// types among holders, given by ref option of cruder tag or by names like
+// AuthorID
+func resolveReferences(holders []*TypeHolder) error {
+	byName := make(map[string]*TypeHolder)
+	for _, holder := range holders {
+		byName[holder.Name] = holder
+	}
+
+	for _, holder := range holders {
+		for i := range holder.Fields {
+			// fields of composite keys can reference other types, like in
+			// relations between them
+			field := &holder.Fields[i]
+			if holder.IsKey(field.Name) && !holder.CompositeKey() {
+				continue
+			}
+
+			name := field.Tags.Ref
+			if len(name) == 0 {
+				name = strings.TrimSuffix(field.Name, "ID")
+				if _, ok := byName[name]; !ok || name == field.Name {
+					continue
+				}
+			}
+
+			referenced, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("Field %v of type %v references unknown type %v", field.Name, holder.Name, name)
+			}
+			if referenced.CompositeKey() {
+				return fmt.Errorf("Field %v of type %v cannot reference type %v as it has a composite key",
+					field.Name, holder.Name, name)
+			}
+			if strings.TrimPrefix(field.Type, "*") != referenced.IDFieldType() {
+				return fmt.Errorf("Field %v of type %v is not of %v id type, %v",
+					field.Name, holder.Name, name, referenced.IDFieldType())
+			}
+
+			field.Ref = &Reference{
+				Type:   name,
+				Table:  referenced.TableName(),
+				Column: referenced.IDFieldColumn(),
+				UUID:   referenced.UUIDKey(),
+			}
+		}
+	}
+	return nil
+}
+
+// sortByReferences sorts holders so that referenced types come before the ones
+// referencing them, keeping the order of the source file otherwise
+func sortByReferences(holders []*TypeHolder) ([]*TypeHolder, error) {
+	sorted := []*TypeHolder{}
+	placed := make(map[string]bool)
+	for len(sorted) < len(holders) {
+		progress := false
+		for _, holder := range holders {
+			if placed[holder.Name] || !referencesPlaced(holder, placed) {
+				continue
+			}
+			sorted = append(sorted, holder)
+			placed[holder.Name] = true
+			progress = true
+			break
+		}
+
+		if !progress {
+			pending := []string{}
+			for _, holder := range holders {
+				if !placed[holder.Name] {
+					pending = append(pending, holder.Name)
+				}
+			}
+			return []*TypeHolder{}, fmt.Errorf("Found circular references between types %v", strings.Join(pending, ", "))
+		}
+	}
+	return sorted, nil
+}
+
+// referencesPlaced returns true if the types referenced by holder, other than
+// itself, are already placed
+func referencesPlaced(holder *TypeHolder, placed map[string]bool) bool {
+	for _, field := range holder.Fields {
+		if field.Ref != nil && field.Ref.Type != holder.Name && !placed[field.Ref.Type] {
+			return false
+		}
+	}
+	return true
+}
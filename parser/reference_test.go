@@ -0,0 +1,226 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+
+	"github.com/rmescandon/cruder/io"
+
+	check "gopkg.in/check.v1"
+)
+
+type ReferenceSuite struct{}
+
+var _ = check.Suite(&ReferenceSuite{})
+
+func composeTestTypeHolders(c *check.C, sources ...string) ([]*TypeHolder, error) {
+	var files []*io.GoFile
+	for i, source := range sources {
+		content, err := io.NewContent(source)
+		c.Assert(err, check.IsNil)
+		files = append(files, &io.GoFile{Path: fmt.Sprintf("file%d.go", i), Content: *content})
+	}
+	return ComposeTypeHolders(files...)
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_references(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type Book struct {
+		ID       int
+		Title    string
+		AuthorID int
+		EditorID *int `+"`cruder:\"ref=Author\"`"+`
+		ParentID int  `+"`cruder:\"ref=Book\"`"+`
+		PageID   int
+	}
+
+	type Author struct {
+		Code int `+"`cruder:\"id\"`"+`
+		Name string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 2)
+
+	// referenced types come first
+	c.Assert(th[0].Name, check.Equals, "Author")
+	c.Assert(th[1].Name, check.Equals, "Book")
+
+	book := th[1]
+	c.Assert(book.Fields[2].Ref, check.DeepEquals, &Reference{Type: "Author", Table: "author", Column: "code"})
+	c.Assert(book.Fields[3].Ref, check.DeepEquals, &Reference{Type: "Author", Table: "author", Column: "code"})
+	c.Assert(book.Fields[4].Ref, check.DeepEquals, &Reference{Type: "Book", Table: "book", Column: "id"})
+	c.Assert(book.Fields[5].Ref, check.IsNil)
+
+	references := book.References()
+	c.Assert(references, check.HasLen, 2)
+	c.Assert(references[0].Name, check.Equals, "AuthorID")
+	c.Assert(references[1].Name, check.Equals, "ParentID")
+
+	columns := book.Columns()
+	c.Assert(columns[2].References, check.Equals, "author(code)")
+	c.Assert(columns[1].References, check.Equals, "")
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_unknownReference(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type Book struct {
+		ID       int
+		WriterID int `+"`cruder:\"ref=Writer\"`"+`
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Field WriterID of type Book references unknown type Writer")
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_referenceTypeMismatch(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type Book struct {
+		ID       int
+		AuthorID string
+	}
+
+	type Author struct {
+		ID   int
+		Name string
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Field AuthorID of type Book is not of Author id type, int")
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_compositeKeyReferences(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type BookTag struct {
+		BookID int    `+"`cruder:\"id\"`"+`
+		TagID  string `+"`cruder:\"id,ref=Tag\"`"+`
+		Weight int
+	}
+
+	type Book struct {
+		ID    int
+		Title string
+	}
+
+	type Tag struct {
+		Name        string
+		Description string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 3)
+
+	bookTag := th[2]
+	c.Assert(bookTag.Name, check.Equals, "BookTag")
+	c.Assert(bookTag.Fields[0].Ref, check.DeepEquals, &Reference{Type: "Book", Table: "book", Column: "id"})
+	c.Assert(bookTag.Fields[1].Ref, check.DeepEquals, &Reference{Type: "Tag", Table: "tag", Column: "name"})
+	c.Assert(bookTag.Columns()[1].References, check.Equals, "tag(name)")
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_uuidReferences(c *check.C) {
+	th, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type BookTag struct {
+		BookID  string `+"`cruder:\"id,ref=Book\"`"+`
+		TagName string `+"`cruder:\"id\"`"+`
+		Weight  int
+	}
+
+	type Review struct {
+		ID     int
+		BookID string
+	}
+
+	type Book struct {
+		Code  string `+"`cruder:\"id=uuid\"`"+`
+		Title string
+	}
+	`)
+	c.Assert(err, check.IsNil)
+	c.Assert(th, check.HasLen, 3)
+
+	bookTag := th[1]
+	c.Assert(bookTag.Name, check.Equals, "BookTag")
+	c.Assert(bookTag.Fields[0].Ref, check.DeepEquals, &Reference{Type: "Book", Table: "book", Column: "code", UUID: true})
+	c.Assert(bookTag.Fields[0].RouteVarPattern(), check.Equals, uuidPattern)
+
+	review := th[2]
+	c.Assert(review.Name, check.Equals, "Review")
+	c.Assert(review.Fields[1].RouteVarPattern(), check.Equals, uuidPattern)
+
+	for _, dialect := range []Dialect{SQLite3, Postgres, MySQL} {
+		bookTag.Dialect = dialect
+		review.Dialect = dialect
+		c.Assert(bookTag.Columns()[0].Definition, check.Equals, "bookid "+dialect.UUIDType()+" not null")
+		c.Assert(review.Columns()[1].Definition, check.Equals, "bookid "+dialect.UUIDType())
+	}
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_referenceCompositeKey(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type Book struct {
+		ID        int
+		EditionID int
+	}
+
+	type Edition struct {
+		BookID int `+"`cruder:\"id,ref=Book\"`"+`
+		Number int `+"`cruder:\"id\"`"+`
+		Year   int
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Field EditionID of type Book cannot reference type Edition as it has a composite key")
+}
+
+func (s *ReferenceSuite) TestComposeTypeHolders_circularReferences(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package mytype
+
+	type Book struct {
+		ID       int
+		AuthorID int
+	}
+
+	type Author struct {
+		ID     int
+		BookID int
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Found circular references between types Book, Author")
+}
+
+func (s *ReferenceSuite) TestRouteVar(c *check.C) {
+	f := TypeField{Name: "AuthorID", Type: "*int"}
+	c.Assert(f.RouteVar(), check.Equals, "authorid")
+	c.Assert(f.RouteVarParse(), check.Equals, `strconv.Atoi(vars["authorid"])`)
+	c.Assert(f.RouteVarPattern(), check.Equals, "[0-9]+")
+	c.Assert(f.RouteVarFormat("b"), check.Equals, "strconv.Itoa(*b.AuthorID)")
+	c.Assert(f.RouteVarOpenAPISchema(), check.Equals, "{type: integer}")
+}
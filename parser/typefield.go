@@ -0,0 +1,345 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	cruderTagKey = "cruder"
+	jsonTagKey   = "json"
+	dbTagKey     = "db"
+)
+
+// TypeField holds a field in a type
+type TypeField struct {
+	Name string
+	Type string
+	Tags FieldTags
+	// Ref is set when the field holds the id of another type register
+	Ref *Reference
+}
+
+// FieldTags holds the struct tag values of a field that are meaningful
+// when generating code. A field like:
+//
+//	Name string `cruder:"search,column=full_name,required,max=50" json:"name"`
+//
+// is flagged as searchable and required, stored in full_name column,
+// serialized as name and validated to be at most 50 characters long
+type FieldTags struct {
+	ID bool
+	// UUID makes the id field get generated uuids, set with id=uuid
+	UUID   bool
+	Search bool
+	// SearchNoCase makes searches of the field ignore case, set with search=nocase
+	SearchNoCase bool
+	Required     bool
+	Column       string
+	JSON         string
+	DB           string
+	// Ref is the name of the type whose id the field holds
+	Ref string
+	// Min and Max are the bounds of numbers, or of the length of strings and slices
+	Min     string
+	Max     string
+	Pattern string
+	Enum    []string
+}
+
+// ColumnName returns the name of the database column for the field. Column
+// in cruder tag has precedence over db tag. If none of them is set, lower
+// case field name is returned
+func (f *TypeField) ColumnName() string {
+	if len(f.Tags.Column) > 0 {
+		return f.Tags.Column
+	}
+
+	if len(f.Tags.DB) > 0 {
+		return f.Tags.DB
+	}
+
+	return strings.ToLower(f.Name)
+}
+
+// JSONName returns the name of the field when serialized as json
+func (f *TypeField) JSONName() string {
+	if len(f.Tags.JSON) > 0 {
+		return f.Tags.JSON
+	}
+	return f.Name
+}
+
+// ValueKind returns the kind of value the field holds when used to sort or
+// filter lists: "string", "int", "float", "bool" or "time". Empty for fields
+// that cannot be compared, like slices
+func (f *TypeField) ValueKind() string {
+	switch ddlType(f.Type) {
+	case "varchar":
+		return "string"
+	case "integer", "bigint":
+		return "int"
+	case "real", "double precision":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "timestamp":
+		return "time"
+	default:
+		return ""
+	}
+}
+
+// IsSerialized returns true if the field is stored serialized as json text,
+// like slices other than []byte
+func (f *TypeField) IsSerialized() bool {
+	return ddlType(f.Type) == "text"
+}
+
+// OpenAPISchema returns the OpenAPI schema of the field value in yaml flow
+// style, like "{type: integer, format: int64}"
+func (f *TypeField) OpenAPISchema() string {
+	return openAPISchema(f.Type, nil)
+}
+
+// OpenAPIPropertySchema returns the OpenAPI schema of the field as a property
+// of its type, along with its validation rules, like "{type: string, maxLength: 50}"
+func (f *TypeField) OpenAPIPropertySchema() string {
+	return openAPISchema(f.Type, f.openAPIRules())
+}
+
+// openAPISchema returns the schema of a type with the given rules, which apply
+// to the base values of pointers and sql.Null types and to slices themselves
+func openAPISchema(t string, rules []string) string {
+	if strings.HasPrefix(t, "*") {
+		schema := openAPISchema(t[1:], rules)
+		if schema == "{}" {
+			return schema
+		}
+		return strings.TrimSuffix(schema, "}") + ", nullable: true}"
+	}
+
+	switch t {
+	case "sql.NullString", "sql.NullInt64", "sql.NullFloat64", "sql.NullBool":
+		// serialized as a struct holding the value and whether it is valid
+		valueField := strings.TrimPrefix(t, "sql.Null")
+		valueType := strings.ToLower(valueField)
+		return fmt.Sprintf("{type: object, properties: {%v: %v, Valid: {type: boolean}}}",
+			valueField, openAPISchema(valueType, rules))
+	}
+
+	schema := openAPITypeSchema(t)
+	if len(rules) == 0 || schema == "{}" {
+		return schema
+	}
+	return strings.TrimSuffix(schema, "}") + ", " + strings.Join(rules, ", ") + "}"
+}
+
+// openAPITypeSchema returns the schema of the values of a type, with no rules
+func openAPITypeSchema(t string) string {
+	switch t {
+	case "string":
+		return "{type: string}"
+	case "int", "int8", "int16", "uint", "uint8", "uint16":
+		return "{type: integer}"
+	case "int32", "uint32":
+		return "{type: integer, format: int32}"
+	case "int64", "uint64":
+		return "{type: integer, format: int64}"
+	case "float32":
+		return "{type: number, format: float}"
+	case "float64":
+		return "{type: number, format: double}"
+	case "bool":
+		return "{type: boolean}"
+	case "time.Time":
+		return "{type: string, format: date-time}"
+	case "[]byte":
+		return "{type: string, format: byte}"
+	case "json.RawMessage", "interface{}":
+		return "{}"
+	}
+
+	if strings.HasPrefix(t, "[]") {
+		return "{type: array, items: " + openAPISchema(t[2:], nil) + "}"
+	}
+
+	return "{type: object}"
+}
+
+// SampleValue returns a Go expression evaluating to a sample value for the
+// field, different for every value of an int variable named n when its
+// validation rules allow it
+func (f *TypeField) SampleValue() string {
+	return f.sampleValue(f.Type)
+}
+
+func (f *TypeField) sampleValue(t string) string {
+	if strings.HasPrefix(t, "*") {
+		return fmt.Sprintf("func() %v { v := %v; return &v }()", t, f.sampleValue(t[1:]))
+	}
+
+	switch t {
+	case "string":
+		if sample := f.sampleString(); len(sample) > 0 {
+			return sample
+		}
+		// referenced ids are used in the paths of nested routes
+		if f.Ref != nil && f.Ref.UUID {
+			return "fmt.Sprintf(\"00000000-0000-4000-8000-%012d\", n)"
+		}
+		if f.Ref != nil {
+			return fmt.Sprintf("fmt.Sprintf(\"%v-%%d\", n)", strings.ToLower(f.Name))
+		}
+		return fmt.Sprintf("fmt.Sprintf(\"%v %%d\", n)", f.Name)
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if sample := f.sampleNumber(t); len(sample) > 0 {
+			return sample
+		}
+		return t + "(n)"
+	case "float32", "float64":
+		if sample := f.sampleNumber(t); len(sample) > 0 {
+			return sample
+		}
+		return t + "(n) + 0.5"
+	case "bool":
+		return "n%2 == 0"
+	case "time.Time":
+		return "time.Date(2018, 1, n, 0, 0, 0, 0, time.UTC)"
+	case "[]byte", "json.RawMessage":
+		return fmt.Sprintf("%v(fmt.Sprintf(\"%%d\", n))", t)
+	case "sql.NullString", "sql.NullInt64", "sql.NullFloat64", "sql.NullBool":
+		valueField := strings.TrimPrefix(t, "sql.Null")
+		return fmt.Sprintf("%v{%v: %v, Valid: true}", t, valueField, f.sampleValue(strings.ToLower(valueField)))
+	}
+
+	if strings.HasPrefix(t, "[]") {
+		// rules of slices are about their length, not about their items
+		item := TypeField{Name: f.Name}
+		return fmt.Sprintf("%v{%v}", t, item.sampleValue(t[2:]))
+	}
+
+	// zero value of any other type
+	return fmt.Sprintf("*new(%v)", t)
+}
+
+// parseFieldTags parses the raw (unquoted) tag of a struct field
+func parseFieldTags(tag string) (FieldTags, error) {
+	structTag := reflect.StructTag(tag)
+
+	tags := FieldTags{
+		JSON: tagName(structTag.Get(jsonTagKey)),
+		DB:   tagName(structTag.Get(dbTagKey)),
+	}
+
+	// every field is stored, so they can't be left out of the table as usual
+	if tags.DB == "-" {
+		return FieldTags{}, fmt.Errorf("Invalid column name %q in %v tag, as all fields are stored", tags.DB, dbTagKey)
+	}
+
+	cruder := structTag.Get(cruderTagKey)
+	if len(cruder) == 0 {
+		return tags, nil
+	}
+
+	options := strings.Split(cruder, ",")
+	for i := 0; i < len(options); i++ {
+		option := strings.TrimSpace(options[i])
+
+		key, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			key, value = option[:i], option[i+1:]
+		}
+
+		switch key {
+		case "":
+			continue
+		case "id":
+			switch value {
+			case "":
+			case "uuid":
+				tags.UUID = true
+			default:
+				return FieldTags{}, fmt.Errorf("Invalid id kind %q in %v tag", value, cruderTagKey)
+			}
+			tags.ID = true
+		case "search":
+			switch value {
+			case "":
+			case "nocase":
+				tags.SearchNoCase = true
+			default:
+				return FieldTags{}, fmt.Errorf("Invalid search mode %q in %v tag", value, cruderTagKey)
+			}
+			tags.Search = true
+		case "required":
+			tags.Required = true
+		case "column":
+			if len(value) == 0 {
+				return FieldTags{}, fmt.Errorf("Empty column name in %v tag", cruderTagKey)
+			}
+			if value == "-" {
+				return FieldTags{}, fmt.Errorf("Invalid column name %q in %v tag, as all fields are stored", value, cruderTagKey)
+			}
+			tags.Column = value
+		case "ref":
+			if len(value) == 0 {
+				return FieldTags{}, fmt.Errorf("Empty referenced type in %v tag", cruderTagKey)
+			}
+			tags.Ref = value
+		case "min", "max":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return FieldTags{}, fmt.Errorf("Invalid %v value %q in %v tag", key, value, cruderTagKey)
+			}
+			if key == "min" {
+				tags.Min = value
+			} else {
+				tags.Max = value
+			}
+		case "enum":
+			if len(value) == 0 {
+				return FieldTags{}, fmt.Errorf("Empty enum values in %v tag", cruderTagKey)
+			}
+			tags.Enum = strings.Split(value, "|")
+		case "pattern":
+			// patterns can hold commas, so they take the rest of the tag
+			value = strings.Join(append([]string{value}, options[i+1:]...), ",")
+			i = len(options)
+			if _, err := regexp.Compile(value); err != nil {
+				return FieldTags{}, fmt.Errorf("Invalid pattern in %v tag: %v", cruderTagKey, err)
+			}
+			tags.Pattern = value
+		default:
+			return FieldTags{}, fmt.Errorf("Unknown option %q in %v tag", key, cruderTagKey)
+		}
+	}
+
+	return tags, nil
+}
+
+// tagName returns the name part of tag values like json:"name,omitempty"
+func tagName(value string) string {
+	return strings.Split(value, ",")[0]
+}
@@ -0,0 +1,217 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type TypeFieldSuite struct{}
+
+var _ = check.Suite(&TypeFieldSuite{})
+
+func (s *TypeFieldSuite) TestParseFieldTags(c *check.C) {
+	tags, err := parseFieldTags(`cruder:"id,search,column=full_name,required" json:"name,omitempty" db:"the_name"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags.ID, check.Equals, true)
+	c.Assert(tags.Search, check.Equals, true)
+	c.Assert(tags.Required, check.Equals, true)
+	c.Assert(tags.Column, check.Equals, "full_name")
+	c.Assert(tags.JSON, check.Equals, "name")
+	c.Assert(tags.DB, check.Equals, "the_name")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_searchNoCase(c *check.C) {
+	tags, err := parseFieldTags(`cruder:"search=nocase"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{Search: true, SearchNoCase: true})
+
+	_, err = parseFieldTags(`cruder:"search=whatever"`)
+	c.Assert(err, check.ErrorMatches, "Invalid search mode \"whatever\" in cruder tag")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_uuid(c *check.C) {
+	tags, err := parseFieldTags(`cruder:"id=uuid"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{ID: true, UUID: true})
+
+	_, err = parseFieldTags(`cruder:"id=whatever"`)
+	c.Assert(err, check.ErrorMatches, "Invalid id kind \"whatever\" in cruder tag")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_empty(c *check.C) {
+	tags, err := parseFieldTags("")
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{})
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_noCruderTag(c *check.C) {
+	tags, err := parseFieldTags(`json:"name"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{JSON: "name"})
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_unknownOption(c *check.C) {
+	_, err := parseFieldTags(`cruder:"id,whatever"`)
+	c.Assert(err, check.ErrorMatches, "Unknown option \"whatever\" in cruder tag")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_emptyColumn(c *check.C) {
+	_, err := parseFieldTags(`cruder:"column="`)
+	c.Assert(err, check.ErrorMatches, "Empty column name in cruder tag")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_skippedColumn(c *check.C) {
+	_, err := parseFieldTags(`db:"-"`)
+	c.Assert(err, check.ErrorMatches, `Invalid column name "-" in db tag, as all fields are stored`)
+
+	_, err = parseFieldTags(`cruder:"column=-"`)
+	c.Assert(err, check.ErrorMatches, `Invalid column name "-" in cruder tag, as all fields are stored`)
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_validation(c *check.C) {
+	tags, err := parseFieldTags(`cruder:"required,min=2,max=10.5,enum=a|b,pattern=^[a-z]{1,3}$"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{
+		Required: true,
+		Min:      "2",
+		Max:      "10.5",
+		Enum:     []string{"a", "b"},
+		Pattern:  "^[a-z]{1,3}$",
+	})
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_ref(c *check.C) {
+	tags, err := parseFieldTags(`cruder:"ref=Author"`)
+	c.Assert(err, check.IsNil)
+	c.Assert(tags, check.DeepEquals, FieldTags{Ref: "Author"})
+
+	_, err = parseFieldTags(`cruder:"ref="`)
+	c.Assert(err, check.ErrorMatches, "Empty referenced type in cruder tag")
+}
+
+func (s *TypeFieldSuite) TestParseFieldTags_invalidValidation(c *check.C) {
+	_, err := parseFieldTags(`cruder:"min=a"`)
+	c.Assert(err, check.ErrorMatches, "Invalid min value \"a\" in cruder tag")
+
+	_, err = parseFieldTags(`cruder:"enum="`)
+	c.Assert(err, check.ErrorMatches, "Empty enum values in cruder tag")
+
+	_, err = parseFieldTags(`cruder:"pattern=[a-"`)
+	c.Assert(err, check.ErrorMatches, "Invalid pattern in cruder tag: .*")
+}
+
+func (s *TypeFieldSuite) TestColumnName(c *check.C) {
+	f := TypeField{Name: "FullName", Type: "string"}
+	c.Assert(f.ColumnName(), check.Equals, "fullname")
+
+	f.Tags.DB = "db_name"
+	c.Assert(f.ColumnName(), check.Equals, "db_name")
+
+	f.Tags.Column = "full_name"
+	c.Assert(f.ColumnName(), check.Equals, "full_name")
+}
+
+func (s *TypeFieldSuite) TestJSONName(c *check.C) {
+	f := TypeField{Name: "FullName", Type: "string"}
+	c.Assert(f.JSONName(), check.Equals, "FullName")
+
+	f.Tags.JSON = "full_name"
+	c.Assert(f.JSONName(), check.Equals, "full_name")
+}
+
+func (s *TypeFieldSuite) TestValueKind(c *check.C) {
+	kinds := map[string]string{
+		"string":     "string",
+		"*string":    "string",
+		"int64":      "int",
+		"float64":    "float",
+		"float32":    "float",
+		"bool":       "bool",
+		"time.Time":  "time",
+		"[]string":   "",
+		"[]byte":     "",
+		"OtherThing": "",
+	}
+	for t, kind := range kinds {
+		f := TypeField{Name: "Field", Type: t}
+		c.Assert(f.ValueKind(), check.Equals, kind, check.Commentf("type %v", t))
+	}
+}
+
+func (s *TypeFieldSuite) TestSampleValue(c *check.C) {
+	samples := map[string]string{
+		"string":         `fmt.Sprintf("Field %d", n)`,
+		"*string":        `func() *string { v := fmt.Sprintf("Field %d", n); return &v }()`,
+		"int64":          "int64(n)",
+		"float64":        "float64(n) + 0.5",
+		"bool":           "n%2 == 0",
+		"time.Time":      "time.Date(2018, 1, n, 0, 0, 0, 0, time.UTC)",
+		"[]byte":         `[]byte(fmt.Sprintf("%d", n))`,
+		"[]int":          "[]int{int(n)}",
+		"sql.NullString": `sql.NullString{String: fmt.Sprintf("Field %d", n), Valid: true}`,
+		"pkg.External":   "*new(pkg.External)",
+	}
+	for t, sample := range samples {
+		f := TypeField{Name: "Field", Type: t}
+		c.Assert(f.SampleValue(), check.Equals, sample, check.Commentf("type %v", t))
+	}
+}
+
+func (s *TypeFieldSuite) TestSampleValue_references(c *check.C) {
+	f := TypeField{Name: "TagName", Type: "string", Ref: &Reference{Type: "Tag"}}
+	c.Assert(f.SampleValue(), check.Equals, `fmt.Sprintf("tagname-%d", n)`)
+
+	f = TypeField{Name: "BookID", Type: "*string", Ref: &Reference{Type: "Book", UUID: true}}
+	c.Assert(f.SampleValue(), check.Equals,
+		`func() *string { v := fmt.Sprintf("00000000-0000-4000-8000-%012d", n); return &v }()`)
+}
+
+func (s *TypeFieldSuite) TestNonGoTypes(c *check.C) {
+	// float and decimal are not Go types, so they are handled as other ones
+	for _, t := range []string{"float", "decimal"} {
+		f := TypeField{Name: "Field", Type: t}
+		c.Assert(ddlType(t), check.Equals, "", check.Commentf("type %v", t))
+		c.Assert(f.OpenAPISchema(), check.Equals, "{type: object}", check.Commentf("type %v", t))
+		c.Assert(f.SampleValue(), check.Equals, "*new("+t+")", check.Commentf("type %v", t))
+		c.Assert(ruleTarget(t), check.Equals, "", check.Commentf("type %v", t))
+	}
+}
+
+func (s *TypeFieldSuite) TestOpenAPISchema(c *check.C) {
+	schemas := map[string]string{
+		"string":          "{type: string}",
+		"*string":         "{type: string, nullable: true}",
+		"int":             "{type: integer}",
+		"int64":           "{type: integer, format: int64}",
+		"float64":         "{type: number, format: double}",
+		"bool":            "{type: boolean}",
+		"time.Time":       "{type: string, format: date-time}",
+		"[]byte":          "{type: string, format: byte}",
+		"[]string":        "{type: array, items: {type: string}}",
+		"json.RawMessage": "{}",
+		"sql.NullInt64":   "{type: object, properties: {Int64: {type: integer, format: int64}, Valid: {type: boolean}}}",
+		"Author":          "{type: object}",
+	}
+	for t, schema := range schemas {
+		f := TypeField{Name: "Field", Type: t}
+		c.Assert(f.OpenAPISchema(), check.Equals, schema, check.Commentf("type %v", t))
+	}
+}
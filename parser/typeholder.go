@@ -22,6 +22,7 @@ package parser
 import (
 	"fmt"
 	"go/ast"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -37,16 +38,16 @@ const (
 
 // TypeHolder holds a type previously read from file
 type TypeHolder struct {
-	Name   string
-	Source *io.GoFile
-	Fields []TypeField
-	Decl   *ast.GenDecl
-}
-
-// TypeField holds a field in a type
-type TypeField struct {
-	Name string
-	Type string
+	Name    string
+	Source  *io.GoFile
+	Fields  []TypeField
+	Decl    *ast.GenDecl
+	Dialect Dialect
+	// Operations supported by the type REST resource. All of them if empty
+	Operations []string
+	// Audit is set when the type table keeps the creation, update and deletion
+	// times of the registers, which are deleted softly
+	Audit bool
 }
 
 // Identifier returns type name in camel case, except first letter, which is lower case:
@@ -58,48 +59,210 @@ func (holder *TypeHolder) Identifier() string {
 	return ""
 }
 
-// IDFieldName returns the name of the first field, taken as ID
-func (holder *TypeHolder) IDFieldName() string {
+// Imports returns the imports of the source file that type declaration needs
+func (holder *TypeHolder) Imports() []*ast.ImportSpec {
+	imports := []*ast.ImportSpec{}
+	if holder.Source == nil || holder.Source.Ast == nil || holder.Decl == nil {
+		return imports
+	}
+
+	qualifiers := make(map[string]bool)
+	ast.Inspect(holder.Decl, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				qualifiers[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	for _, imp := range holder.Source.Ast.Imports {
+		if qualifiers[importName(imp)] {
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
+// SampleImports returns the import specs, like "time" or sql "database/sql",
+// needed by the sample values of the fields other than a generated key
+func (holder *TypeHolder) SampleImports() []string {
+	imports := []string{}
+	qualifiers := make(map[string]bool)
+	for _, field := range holder.Fields {
+		sample := field.SampleValue()
+		if holder.IsKey(field.Name) {
+			if !holder.ClientKey() {
+				continue
+			}
+			sample = field.KeySampleValue()
+		}
+
+		if strings.Contains(sample, "fmt.") && !qualifiers["fmt"] {
+			imports = append(imports, strconv.Quote("fmt"))
+		}
+		for _, match := range qualifierRegexp.FindAllStringSubmatch(sample, -1) {
+			qualifiers[match[1]] = true
+		}
+	}
+
+	for _, imp := range holder.Imports() {
+		name := importName(imp)
+		if !qualifiers[name] {
+			continue
+		}
+		if imp.Name != nil {
+			imports = append(imports, imp.Name.Name+" "+imp.Path.Value)
+		} else {
+			imports = append(imports, imp.Path.Value)
+		}
+	}
+	return imports
+}
+
+var qualifierRegexp = regexp.MustCompile(`\b([a-zA-Z_][a-zA-Z0-9_]*)\.`)
+
+// importName returns the name an import is referred by in source code
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+
+	path, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return ""
+	}
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// idField returns the field tagged as id or, if none is, the first field
+func (holder *TypeHolder) idField() *TypeField {
 	if len(holder.Fields) == 0 {
+		return nil
+	}
+
+	for i := range holder.Fields {
+		if holder.Fields[i].Tags.ID {
+			return &holder.Fields[i]
+		}
+	}
+	return &holder.Fields[0]
+}
+
+// findField returns the first field tagged as search or, if none is,
+// the second field or the only one if there is just one
+func (holder *TypeHolder) findField() *TypeField {
+	for i := range holder.Fields {
+		if holder.Fields[i].Tags.Search {
+			return &holder.Fields[i]
+		}
+	}
+
+	switch len(holder.Fields) {
+	case 0:
+		return nil
+	case 1:
+		return &holder.Fields[0]
+	default:
+		return &holder.Fields[1]
+	}
+}
+
+// SearchFields returns the fields searched for a text in lists: the ones tagged
+// as search or, if none is, the second field when it is a string
+func (holder *TypeHolder) SearchFields() []TypeField {
+	fields := []TypeField{}
+	for _, field := range holder.Fields {
+		if field.Tags.Search {
+			fields = append(fields, field)
+		}
+	}
+
+	if f := holder.findField(); len(fields) == 0 && f != nil && f.Type == "string" {
+		fields = append(fields, *f)
+	}
+	return fields
+}
+
+// IDFieldName returns the name of the field taken as ID
+func (holder *TypeHolder) IDFieldName() string {
+	f := holder.idField()
+	if f == nil {
 		return ""
 	}
-	return holder.Fields[0].Name
+	return f.Name
 }
 
-// IDFieldType returns the type of the first field, taken as ID
+// IDFieldType returns the type of the field taken as ID
 func (holder *TypeHolder) IDFieldType() string {
-	if len(holder.Fields) == 0 {
+	f := holder.idField()
+	if f == nil {
+		return ""
+	}
+	return f.Type
+}
+
+// IDFieldColumn returns the database column of the field taken as ID
+func (holder *TypeHolder) IDFieldColumn() string {
+	f := holder.idField()
+	if f == nil {
+		return ""
+	}
+	return f.ColumnName()
+}
+
+// IDFieldJSONName returns the name of the field taken as ID when serialized as json
+func (holder *TypeHolder) IDFieldJSONName() string {
+	f := holder.idField()
+	if f == nil {
 		return ""
 	}
-	return holder.Fields[0].Type
+	return f.JSONName()
+}
+
+// IDFieldOpenAPISchema returns the OpenAPI schema of the field taken as ID
+func (holder *TypeHolder) IDFieldOpenAPISchema() string {
+	f := holder.idField()
+	if f == nil {
+		return ""
+	}
+	return f.OpenAPISchema()
 }
 
 // FindFieldName return the name of the field used for searches
 func (holder *TypeHolder) FindFieldName() string {
-	switch len(holder.Fields) {
-	case 0:
+	f := holder.findField()
+	if f == nil {
+		return ""
+	}
+	return f.Name
+}
+
+// FindFieldColumn return the database column of the field used for searches
+func (holder *TypeHolder) FindFieldColumn() string {
+	f := holder.findField()
+	if f == nil {
 		return ""
-	case 1:
-		return holder.Fields[0].Name
-	default:
-		return holder.Fields[1].Name
 	}
+	return f.ColumnName()
 }
 
 // FieldsEnum returns enum of the fields including type indentifier and field name:
-// "theType.Field1, theType.Field2, theType.FieldN"
+// "theType.Field1, theType.Field2, theType.FieldN". Serialized fields are
+// wrapped in a Serialized value: "Serialized{V: theType.Tags}"
 func (holder *TypeHolder) FieldsEnum() string {
-	return holder.fieldsEnum(false)
+	return holder.fieldsEnum(false, "")
 }
 
 // FieldsEnumRef returns enum of type fields, including type identifiera and field name reference:
 // "&theType.Field1, &theType.Field2, &theType.FieldN"
 func (holder *TypeHolder) FieldsEnumRef() string {
-	return holder.fieldsEnum(true)
+	return holder.fieldsEnum(true, "")
 }
 
-// depending on the bool param returns the same as typeRefFieldsEnum or typeFieldsEnum
-func (holder *TypeHolder) fieldsEnum(asRef bool) string {
+// depending on the bool param returns the same as typeRefFieldsEnum or typeFieldsEnum.
+// pkg qualifies the Serialized wrapper when used out of datastore package
+func (holder *TypeHolder) fieldsEnum(asRef bool, pkg string) string {
 	ref := ""
 	if asRef {
 		ref = "&"
@@ -107,12 +270,15 @@ func (holder *TypeHolder) fieldsEnum(asRef bool) string {
 
 	tokens := []string{}
 	for _, field := range holder.Fields {
-		// skip ID field
-		if field.Name == holder.IDFieldName() {
+		// skip key fields
+		if holder.IsKey(field.Name) {
 			continue
 		}
 
 		token := ref + holder.identifierDotField(field.Name)
+		if field.IsSerialized() {
+			token = pkg + "Serialized{V: " + token + "}"
+		}
 		tokens = append(tokens, token)
 	}
 
@@ -127,35 +293,47 @@ func (holder *TypeHolder) identifierDotField(fieldName string) string {
 
 // IDFieldInDDL returns the IDField as seen in SQL DDL operations
 func (holder *TypeHolder) IDFieldInDDL() string {
-	id := strings.ToLower(holder.IDFieldName())
-	if len(id) == 0 {
-		return ""
-	}
-
-	t := ddlType(holder.IDFieldType())
-	if len(t) == 0 {
+	column := holder.Dialect.IDColumnInDDL(holder.IDFieldColumn(), holder.IDFieldType())
+	if len(column) == 0 {
 		return ""
 	}
+	return column + ","
+}
 
-	return id + " " + t + " primary key not null,"
+// SQLType returns the SQL column type for a go type in default dialect
+func SQLType(t string) string {
+	return ddlType(t)
 }
 
 func ddlType(t string) string {
+	// pointers are mapped as their base types. They are nullable columns
+	t = strings.TrimPrefix(t, "*")
+
 	switch t {
-	case "string":
+	case "string", "sql.NullString":
 		return "varchar"
-	case "int":
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32", "sql.NullInt64":
 		return "integer"
-	case "decimal":
-		return "decimal"
-	case "bool":
+	case "int64", "uint64":
+		return "bigint"
+	case "float32":
+		return "real"
+	case "float64", "sql.NullFloat64":
+		return "double precision"
+	case "bool", "sql.NullBool":
 		return "boolean"
 	case "time.Time":
 		return "timestamp"
-		// FIXME fill the rest of the types
-	default:
-		return ""
+	case "[]byte", "json.RawMessage":
+		return "blob"
+	}
+
+	// other slices are stored serialized as json
+	if strings.HasPrefix(t, "[]") {
+		return "text"
 	}
+
+	return ""
 }
 
 // FieldsInDDL returns the type fields as they are used for SQL DDL operations, like:
@@ -165,61 +343,152 @@ func ddlType(t string) string {
 func (holder *TypeHolder) FieldsInDDL() string {
 	tokens := []string{}
 	for _, field := range holder.Fields {
-		// skip the ID field, show the rest
-		if field.Name == holder.IDFieldName() {
+		// skip key fields, show the rest
+		if holder.IsKey(field.Name) {
 			continue
 		}
 
-		t := ddlType(field.Type)
-		token := fmt.Sprintf("%v %v", strings.ToLower(field.Name), t)
-		tokens = append(tokens, token)
+		tokens = append(tokens, holder.fieldInDDL(field))
 	}
 
 	return strings.Join(tokens, ",\n")
 }
 
+func (holder *TypeHolder) fieldInDDL(field TypeField) string {
+	token := fmt.Sprintf("%v %v", field.ColumnName(), holder.fieldSQLType(field))
+	if field.Tags.Required {
+		token += " not null"
+	}
+	return token
+}
+
+// fieldSQLType returns the column type of a field. The ones referencing uuid
+// keys get the type of those, as foreign keys must match the referenced ones
+func (holder *TypeHolder) fieldSQLType(field TypeField) string {
+	if field.Ref != nil && field.Ref.UUID {
+		return holder.Dialect.UUIDType()
+	}
+	return holder.Dialect.SQLType(field.Type)
+}
+
+// Column holds the name and the SQL definition of a table column
+type Column struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+	// References is the referenced table and column, like "author(id)"
+	References string `json:"references,omitempty"`
+	// PrimaryKey is set for the columns of a composite key, declared apart
+	PrimaryKey bool `json:"primary_key,omitempty"`
+}
+
+// TableName returns the name of the database table for the type
+func (holder *TypeHolder) TableName() string {
+	return strings.ToLower(holder.Name)
+}
+
+// Columns returns the columns of the type table, being the key ones the first
+func (holder *TypeHolder) Columns() []Column {
+	columns := []Column{}
+
+	switch {
+	case holder.CompositeKey():
+		for _, field := range holder.KeyFields() {
+			columns = append(columns, holder.column(field, holder.keyInDDL(field)))
+			columns[len(columns)-1].PrimaryKey = true
+		}
+	case holder.UUIDKey():
+		columns = append(columns, Column{Name: holder.IDFieldColumn(),
+			Definition: holder.Dialect.UUIDColumnInDDL(holder.IDFieldColumn())})
+	default:
+		id := holder.Dialect.IDColumnInDDL(holder.IDFieldColumn(), holder.IDFieldType())
+		if len(id) > 0 {
+			columns = append(columns, Column{Name: holder.IDFieldColumn(), Definition: id})
+		}
+	}
+
+	for _, field := range holder.Fields {
+		if holder.IsKey(field.Name) {
+			continue
+		}
+		columns = append(columns, holder.column(field, holder.fieldInDDL(field)))
+	}
+
+	if len(columns) > 0 {
+		columns = append(columns, holder.versionColumn())
+	}
+	if holder.Audit {
+		columns = append(columns, holder.auditColumns()...)
+	}
+
+	return columns
+}
+
+// column returns the column of a field with the given definition
+func (holder *TypeHolder) column(field TypeField, definition string) Column {
+	column := Column{Name: field.ColumnName(), Definition: definition}
+	if field.Ref != nil {
+		column.References = fmt.Sprintf("%v(%v)", field.Ref.Table, field.Ref.Column)
+	}
+	return column
+}
+
+// ListFields returns the fields lists of the type can be sorted and filtered by.
+// Those not serialized as json are left out
+func (holder *TypeHolder) ListFields() []TypeField {
+	fields := []TypeField{}
+	for _, field := range holder.Fields {
+		if len(field.ValueKind()) > 0 && field.JSONName() != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 // FieldsInDML returns "field1, field2, field3"
 func (holder *TypeHolder) FieldsInDML() string {
 	tokens := []string{}
 	for _, field := range holder.Fields {
-		// skip the ID field, show the rest
-		if field.Name == holder.IDFieldName() {
+		// skip key fields, show the rest
+		if holder.IsKey(field.Name) {
 			continue
 		}
 
-		tokens = append(tokens, strings.ToLower(field.Name))
+		tokens = append(tokens, field.ColumnName())
 	}
 
 	return strings.Join(tokens, ", ")
 }
 
-// ValuesInDMLParams returns something like "$1, $2, $3"
+// ValuesInDMLParams returns something like "$1, $2, $3" or "?, ?, ?", depending on dialect
 func (holder *TypeHolder) ValuesInDMLParams() string {
 	tokens := []string{}
-	for i := 1; i < len(holder.Fields); i++ {
-		tokens = append(tokens, "$"+strconv.Itoa(i))
+	for i := 1; i <= len(holder.Fields)-len(holder.KeyFields()); i++ {
+		tokens = append(tokens, holder.Dialect.Placeholder(i))
 	}
 	return strings.Join(tokens, ", ")
 }
 
-// IDFieldAsDMLParam returns something like "id=$4"
+// IDFieldAsDMLParam returns something like "id=$4" or "id=?", depending on dialect,
+// numbered after the parameters of FieldsAsDMLParams. Composite keys give
+// conditions like "book_id=$2 and tag_id=$3"
 func (holder *TypeHolder) IDFieldAsDMLParam() string {
 	if len(holder.IDFieldName()) == 0 {
 		return ""
 	}
-	return strings.ToLower(holder.IDFieldName()) + "=$" + strconv.Itoa(len(holder.Fields))
+	return holder.keyCondition(len(holder.Fields) - len(holder.KeyFields()) + 1)
 }
 
-// FieldsAsDMLParams returns something like "field1=$1, field2=$2, field3=$3"
+// FieldsAsDMLParams returns something like "field1=$1, field2=$2, field3=$3" or
+// "field1=?, field2=?, field3=?", depending on dialect
 func (holder *TypeHolder) FieldsAsDMLParams() string {
 	tokens := []string{}
-	for i, field := range holder.Fields {
-		// skip the ID field, show the rest
-		if field.Name == holder.IDFieldName() {
+	for _, field := range holder.Fields {
+		// skip key fields, show the rest
+		if holder.IsKey(field.Name) {
 			continue
 		}
 
-		token := strings.ToLower(field.Name) + "=$" + strconv.Itoa(i)
+		token := field.ColumnName() + "=" + holder.Dialect.Placeholder(len(tokens)+1)
 		tokens = append(tokens, token)
 	}
 	return strings.Join(tokens, ", ")
@@ -227,43 +496,65 @@ func (holder *TypeHolder) FieldsAsDMLParams() string {
 
 // IDFieldTypeParse returns the type parsing instruction for ID field
 func (holder *TypeHolder) IDFieldTypeParse() string {
-	switch holder.IDFieldType() {
+	return typeParse(holder.IDFieldType(), "vars[\""+strings.ToLower(holder.IDFieldName())+"\"]")
+}
+
+// IDFieldTypeFormat returns the type formatting instruction to have ID as string
+func (holder *TypeHolder) IDFieldTypeFormat() string {
+	return typeFormat(holder.IDFieldType(), strings.ToLower(holder.IDFieldName()))
+}
+
+// IDFieldPattern returns the pattern associated with id field type, to be used when routing REST paths
+func (holder *TypeHolder) IDFieldPattern() string {
+	if holder.UUIDKey() {
+		return uuidPattern
+	}
+	return typePattern(holder.IDFieldType())
+}
+
+// typeParse returns the instruction parsing a value of type t from the string expr
+func typeParse(t, expr string) string {
+	switch t {
 	case "int":
-		return "strconv.Atoi(vars[\"" + strings.ToLower(holder.IDFieldName()) + "\"])"
+		return "strconv.Atoi(" + expr + ")"
+	case "int64":
+		return "strconv.ParseInt(" + expr + ", 10, 64)"
 	case "decimal":
-		return "strconv.ParseFloat(vars[\"" + strings.ToLower(holder.IDFieldName()) + "\"])"
+		return "strconv.ParseFloat(" + expr + ")"
 	case "bool":
-		return "strconv.ParseBool(vars[\"" + strings.ToLower(holder.IDFieldName()) + "\"])"
+		return "strconv.ParseBool(" + expr + ")"
 	default:
-		return "vars[\"" + strings.ToLower(holder.IDFieldName()) + "\"]"
+		return expr
 	}
 }
 
-// IDFieldTypeFormat returns the type formatting instruction to have ID as string
-func (holder *TypeHolder) IDFieldTypeFormat() string {
-	switch holder.IDFieldType() {
+// typeFormat returns the instruction formatting expr, of type t, as a string
+func typeFormat(t, expr string) string {
+	switch t {
 	case "int":
-		return "strconv.Itoa(" + strings.ToLower(holder.IDFieldName()) + ")"
+		return "strconv.Itoa(" + expr + ")"
+	case "int64":
+		return "strconv.FormatInt(" + expr + ", 10)"
 	case "decimal":
-		return "strconv.FormatFloat(" + strings.ToLower(holder.IDFieldName()) + ")"
+		return "strconv.FormatFloat(" + expr + ")"
 	case "bool":
-		return "strconv.FormatBool(" + strings.ToLower(holder.IDFieldName()) + ")"
+		return "strconv.FormatBool(" + expr + ")"
 	default:
-		return strings.ToLower(holder.IDFieldName())
+		return expr
 	}
 }
 
-// IDFieldPattern returns the pattern associated with id field type, to be used when routing REST paths
-func (holder *TypeHolder) IDFieldPattern() string {
-	switch holder.IDFieldType() {
-	case "int":
+// typePattern returns the pattern of the values of type t in REST paths
+func typePattern(t string) string {
+	switch t {
+	case "int", "int64":
 		return "[0-9]+"
 	case "decimal":
 		return "^[0-9]+(\\.[0-9]{1,2})?$"
 	case "bool":
 		return "^(?:tru|fals)e$"
 	default:
-		return "[a-zA-Z0-9-_\\.]+"
+		return "[a-zA-Z0-9_.-]+"
 	}
 }
 
@@ -302,9 +593,15 @@ func (holder *TypeHolder) ReplaceInTemplate(templateContent string) string {
 	// int
 	replaced = strings.Replace(replaced, "_#ID.FIELD.TYPE#_", holder.IDFieldType(), -1)
 
-	// name
+	// id
+	replaced = strings.Replace(replaced, "_#ID.FIELD.COLUMN#_", holder.IDFieldColumn(), -1)
+
+	// Name
 	replaced = strings.Replace(replaced, "_#FIND.FIELD.NAME#_", holder.FindFieldName(), -1)
 
+	// name
+	replaced = strings.Replace(replaced, "_#FIND.FIELD.COLUMN#_", holder.FindFieldColumn(), -1)
+
 	// theType.Name, theType.Description, theType.Subtypes
 	replaced = strings.Replace(replaced, "_#FIELDS.ENUM#_", holder.FieldsEnum(), -1)
 
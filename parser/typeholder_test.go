@@ -42,7 +42,7 @@ func (s *TypeHolderSuite) SetUpSuite(c *check.C) {
 		Fields: []TypeField{
 			{Name: "ID", Type: "int"},
 			{Name: "Field1", Type: "string"},
-			{Name: "Field2", Type: "decimal"},
+			{Name: "Field2", Type: "float64"},
 			{Name: "Field3", Type: "int"},
 		},
 	}
@@ -91,6 +91,33 @@ func (s *TypeHolderSuite) TestFindFieldName_oneField(c *check.C) {
 	c.Assert(t.FindFieldName(), check.Equals, "ID")
 }
 
+func (s *TypeHolderSuite) TestSearchFields(c *check.C) {
+	c.Assert(s.typeHolder.SearchFields(), check.DeepEquals, []TypeField{s.typeHolder.Fields[1]})
+
+	h := TypeHolder{
+		Name: "MyType",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "Title", Type: "string", Tags: FieldTags{Search: true}},
+			{Name: "Price", Type: "float64"},
+			{Name: "Summary", Type: "string", Tags: FieldTags{Search: true, SearchNoCase: true}},
+		},
+	}
+	c.Assert(h.SearchFields(), check.DeepEquals, []TypeField{h.Fields[1], h.Fields[3]})
+}
+
+func (s *TypeHolderSuite) TestSearchFields_notString(c *check.C) {
+	h := TypeHolder{
+		Name: "MyType",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "Price", Type: "float64"},
+		},
+	}
+	c.Assert(h.SearchFields(), check.HasLen, 0)
+	c.Assert(s.emptyTypeHolder.SearchFields(), check.HasLen, 0)
+}
+
 func (s *TypeHolderSuite) TestFieldsEnum(c *check.C) {
 	c.Assert(s.typeHolder.FieldsEnum(), check.Equals, "myType.Field1, myType.Field2, myType.Field3")
 }
@@ -107,6 +134,22 @@ func (s *TypeHolderSuite) TestFieldsEnumRef_empty(c *check.C) {
 	c.Assert(s.emptyTypeHolder.FieldsEnumRef(), check.Equals, "")
 }
 
+func (s *TypeHolderSuite) TestFieldsEnum_serialized(c *check.C) {
+	h := TypeHolder{
+		Name: "MyType",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int"},
+			{Name: "Tags", Type: "[]string"},
+			{Name: "Data", Type: "[]byte"},
+		},
+	}
+	c.Assert(h.Fields[1].IsSerialized(), check.Equals, true)
+	c.Assert(h.Fields[2].IsSerialized(), check.Equals, false)
+	c.Assert(h.FieldsEnum(), check.Equals, "Serialized{V: myType.Tags}, myType.Data")
+	c.Assert(h.FieldsEnumRef(), check.Equals, "Serialized{V: &myType.Tags}, &myType.Data")
+	c.Assert(h.InsertValuesIn("datastore"), check.Equals, "datastore.Serialized{V: myType.Tags}, myType.Data")
+}
+
 func (s *TypeHolderSuite) TestTypeInComments(c *check.C) {
 	c.Assert(strings.ToLower(s.typeHolder.Name), check.Equals, "mytype")
 }
@@ -136,20 +179,67 @@ func (s *TypeHolderSuite) TestIDFieldInDDL_otherType(c *check.C) {
 func (s *TypeHolderSuite) TestDDLTypeConversion(c *check.C) {
 	c.Assert(ddlType("string"), check.Equals, "varchar")
 	c.Assert(ddlType("int"), check.Equals, "integer")
-	c.Assert(ddlType("decimal"), check.Equals, "decimal")
+	c.Assert(ddlType("decimal"), check.Equals, "")
 	c.Assert(ddlType("bool"), check.Equals, "boolean")
 	c.Assert(ddlType("time.Time"), check.Equals, "timestamp")
 	c.Assert(ddlType("other"), check.Equals, "")
+
+	c.Assert(ddlType("*string"), check.Equals, "varchar")
+	c.Assert(ddlType("*time.Time"), check.Equals, "timestamp")
+	c.Assert(ddlType("int64"), check.Equals, "bigint")
+	c.Assert(ddlType("float64"), check.Equals, "double precision")
+	c.Assert(ddlType("sql.NullString"), check.Equals, "varchar")
+	c.Assert(ddlType("[]byte"), check.Equals, "blob")
+	c.Assert(ddlType("[]string"), check.Equals, "text")
 }
 
 func (s *TypeHolderSuite) TestFieldsInDDL(c *check.C) {
-	c.Assert(s.typeHolder.FieldsInDDL(), check.Equals, "field1 varchar,\nfield2 decimal,\nfield3 integer")
+	c.Assert(s.typeHolder.FieldsInDDL(), check.Equals, "field1 varchar,\nfield2 double precision,\nfield3 integer")
 }
 
 func (s *TypeHolderSuite) TestFieldsInDDL_empty(c *check.C) {
 	c.Assert(s.emptyTypeHolder.FieldsInDDL(), check.Equals, "")
 }
 
+func (s *TypeHolderSuite) TestTableName(c *check.C) {
+	c.Assert(s.typeHolder.TableName(), check.Equals, "mytype")
+}
+
+func (s *TypeHolderSuite) TestColumns(c *check.C) {
+	c.Assert(s.typeHolder.Columns(), check.DeepEquals, []Column{
+		{Name: "id", Definition: "id integer primary key not null"},
+		{Name: "field1", Definition: "field1 varchar"},
+		{Name: "field2", Definition: "field2 double precision"},
+		{Name: "field3", Definition: "field3 integer"},
+		{Name: "row_version", Definition: "row_version integer not null default 1"},
+	})
+}
+
+func (s *TypeHolderSuite) TestColumns_empty(c *check.C) {
+	c.Assert(s.emptyTypeHolder.Columns(), check.HasLen, 0)
+}
+
+func (s *TypeHolderSuite) TestListFields(c *check.C) {
+	h := TypeHolder{
+		Name: "MyType",
+		Fields: []TypeField{
+			{Name: "ID", Type: "int", Tags: FieldTags{JSON: "id"}},
+			{Name: "Tags", Type: "[]string"},
+			{Name: "Price", Type: "float64"},
+			{Name: "Secret", Type: "string", Tags: FieldTags{JSON: "-"}},
+		},
+	}
+	c.Assert(h.ListFields(), check.DeepEquals, []TypeField{h.Fields[0], h.Fields[2]})
+	c.Assert(h.IDFieldJSONName(), check.Equals, "id")
+	c.Assert(h.IDFieldOpenAPISchema(), check.Equals, "{type: integer}")
+}
+
+func (s *TypeHolderSuite) TestListFields_empty(c *check.C) {
+	c.Assert(s.emptyTypeHolder.ListFields(), check.HasLen, 0)
+	c.Assert(s.emptyTypeHolder.IDFieldJSONName(), check.Equals, "")
+	c.Assert(s.emptyTypeHolder.IDFieldOpenAPISchema(), check.Equals, "")
+}
+
 func (s *TypeHolderSuite) TestFieldsInDML(c *check.C) {
 	c.Assert(s.typeHolder.FieldsInDML(), check.Equals, "field1, field2, field3")
 }
@@ -182,6 +272,28 @@ func (s *TypeHolderSuite) TestFieldsAsDMLParams_empty(c *check.C) {
 	c.Assert(s.emptyTypeHolder.FieldsAsDMLParams(), check.Equals, "")
 }
 
+func (s *TypeHolderSuite) TestDMLParams_mysql(c *check.C) {
+	t := s.typeHolder
+	t.Dialect = MySQL
+	c.Assert(t.ValuesInDMLParams(), check.Equals, "?, ?, ?")
+	c.Assert(t.IDFieldAsDMLParam(), check.Equals, "id=?")
+	c.Assert(t.FieldsAsDMLParams(), check.Equals, "field1=?, field2=?, field3=?")
+}
+
+func (s *TypeHolderSuite) TestDDL_postgres(c *check.C) {
+	t := s.typeHolder
+	t.Dialect = Postgres
+	c.Assert(t.IDFieldInDDL(), check.Equals, "id serial primary key,")
+	c.Assert(t.FieldsInDDL(), check.Equals, "field1 varchar,\nfield2 double precision,\nfield3 integer")
+}
+
+func (s *TypeHolderSuite) TestDDL_mysql(c *check.C) {
+	t := s.typeHolder
+	t.Dialect = MySQL
+	c.Assert(t.IDFieldInDDL(), check.Equals, "id integer not null auto_increment primary key,")
+	c.Assert(t.FieldsInDDL(), check.Equals, "field1 varchar(255),\nfield2 double,\nfield3 integer")
+}
+
 func (s *TypeHolderSuite) TestIDFieldTypeParse(c *check.C) {
 	c.Assert(s.typeHolder.IDFieldTypeParse(),
 		check.Equals,
@@ -286,7 +398,7 @@ func (s *TypeHolderSuite) TestIDFieldPattern(c *check.C) {
 	}
 	c.Assert(t.IDFieldPattern(),
 		check.Equals,
-		"[a-zA-Z0-9-_\\.]+")
+		"[a-zA-Z0-9_.-]+")
 }
 
 func (s *TypeHolderSuite) TestReplaceInTemplate(c *check.C) {
@@ -300,7 +412,7 @@ func (s *TypeHolderSuite) TestReplaceInTemplate(c *check.C) {
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#FIELDS.ENUM#_"), check.Equals, "myType.Field1, myType.Field2, myType.Field3")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#FIELDS.ENUM.REF#_"), check.Equals, "&myType.Field1, &myType.Field2, &myType.Field3")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#ID.FIELD.DDL#_"), check.Equals, "id integer primary key not null,")
-	c.Assert(s.typeHolder.ReplaceInTemplate("_#FIELDS.DDL#_"), check.Equals, "field1 varchar,\nfield2 decimal,\nfield3 integer")
+	c.Assert(s.typeHolder.ReplaceInTemplate("_#FIELDS.DDL#_"), check.Equals, "field1 varchar,\nfield2 double precision,\nfield3 integer")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#FIELDS.DML#_"), check.Equals, "field1, field2, field3")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#VALUES.DML.PARAMS#_"), check.Equals, "$1, $2, $3")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#ID.FIELD.DML.PARAM#_"), check.Equals, "id=$4")
@@ -309,3 +421,29 @@ func (s *TypeHolderSuite) TestReplaceInTemplate(c *check.C) {
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#ID.FIELD.TYPE.FORMAT#_"), check.Equals, "strconv.Itoa(id)")
 	c.Assert(s.typeHolder.ReplaceInTemplate("_#ID.FIELD.PATTERN#_"), check.Equals, "[0-9]+")
 }
+
+func (s *TypeHolderSuite) TestTaggedFields(c *check.C) {
+	t := TypeHolder{
+		Name: "MyType",
+		Fields: []TypeField{
+			{Name: "Name", Type: "string", Tags: FieldTags{Column: "full_name", Required: true}},
+			{Name: "Code", Type: "string", Tags: FieldTags{ID: true, DB: "the_code"}},
+			{Name: "Description", Type: "string", Tags: FieldTags{Search: true}},
+			{Name: "Amount", Type: "int"},
+		},
+	}
+
+	c.Assert(t.IDFieldName(), check.Equals, "Code")
+	c.Assert(t.IDFieldType(), check.Equals, "string")
+	c.Assert(t.IDFieldColumn(), check.Equals, "the_code")
+	c.Assert(t.FindFieldName(), check.Equals, "Description")
+	c.Assert(t.FindFieldColumn(), check.Equals, "description")
+	c.Assert(t.IDFieldInDDL(), check.Equals, "the_code varchar primary key not null,")
+	c.Assert(t.FieldsInDDL(), check.Equals, "full_name varchar not null,\ndescription varchar,\namount integer")
+	c.Assert(t.FieldsInDML(), check.Equals, "full_name, description, amount")
+	c.Assert(t.FieldsEnum(), check.Equals, "myType.Name, myType.Description, myType.Amount")
+	c.Assert(t.FieldsAsDMLParams(), check.Equals, "full_name=$1, description=$2, amount=$3")
+	c.Assert(t.IDFieldAsDMLParam(), check.Equals, "the_code=$4")
+	c.Assert(t.ReplaceInTemplate("_#ID.FIELD.COLUMN#_"), check.Equals, "the_code")
+	c.Assert(t.ReplaceInTemplate("_#FIND.FIELD.COLUMN#_"), check.Equals, "description")
+}
@@ -0,0 +1,285 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+)
+
+// ruleTarget returns the kind of value validation rules check for a field
+// type: "string", "int", "float" or "slice", empty if none. Pointers and
+// sql.Null types are checked by their base values
+func ruleTarget(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	switch t {
+	case "string", "sql.NullString":
+		return "string"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "sql.NullInt64":
+		return "int"
+	case "float32", "float64", "sql.NullFloat64":
+		return "float"
+	}
+
+	if strings.HasPrefix(t, "[]") || t == "json.RawMessage" {
+		return "slice"
+	}
+	return ""
+}
+
+// checkRules returns an error if the validation rules of the field cannot be
+// applied to its type
+func (f *TypeField) checkRules() error {
+	target := ruleTarget(f.Type)
+	if (len(f.Tags.Min) > 0 || len(f.Tags.Max) > 0) && len(target) == 0 {
+		return fmt.Errorf("Field %v: min and max do not apply to %v type", f.Name, f.Type)
+	}
+	if (len(f.Tags.Pattern) > 0 || len(f.Tags.Enum) > 0) && target != "string" {
+		return fmt.Errorf("Field %v: pattern and enum only apply to strings", f.Name)
+	}
+
+	for _, bound := range []string{f.Tags.Min, f.Tags.Max} {
+		if len(bound) == 0 || target == "float" {
+			continue
+		}
+		if _, err := strconv.Atoi(bound); err != nil {
+			return fmt.Errorf("Field %v: %v is not an integer bound", f.Name, bound)
+		}
+	}
+	return nil
+}
+
+// ValidationCode returns the statements checking the validation rules of the
+// field of the struct held by v. Every broken rule appends a FieldError to a
+// fieldErrors variable
+func (f *TypeField) ValidationCode(v string) string {
+	value := v + "." + f.Name
+	t := f.Type
+
+	// rules of optional values are only checked when they are set
+	isSet, isUnset := "", ""
+	switch {
+	case strings.HasPrefix(t, "*"):
+		isSet, isUnset = value+" != nil", value+" == nil"
+		value = "*" + value
+	case strings.HasPrefix(t, "sql.Null"):
+		isSet, isUnset = value+".Valid", "!"+value+".Valid"
+		value = value + "." + strings.TrimPrefix(t, "sql.Null")
+	}
+
+	target := ruleTarget(t)
+
+	var buf bytes.Buffer
+	check := func(condition, message string) {
+		fmt.Fprintf(&buf, "\tif %v {\n", condition)
+		fmt.Fprintf(&buf, "\t\tfieldErrors = append(fieldErrors, FieldError{Field: %q, Message: %q})\n", f.JSONName(), message)
+		buf.WriteString("\t}\n")
+	}
+	checkSet := func(condition, message string) {
+		if len(isSet) > 0 {
+			condition = isSet + " && " + condition
+		}
+		check(condition, message)
+	}
+
+	if f.Tags.Required {
+		switch {
+		case len(isSet) > 0:
+			check(isUnset, "is required")
+		case target == "string":
+			check(value+` == ""`, "is required")
+		case target == "int", target == "float":
+			check(value+" == 0", "is required")
+		case target == "slice":
+			check("len("+value+") == 0", "is required")
+		case t == "time.Time":
+			check(value+".IsZero()", "is required")
+		}
+	}
+
+	// empty strings and slices only break the required rule
+	if len(isSet) == 0 {
+		switch target {
+		case "string":
+			isSet = value + ` != ""`
+		case "slice":
+			isSet = "len(" + value + ") > 0"
+		}
+	}
+
+	length, unit := value, ""
+	switch target {
+	case "string":
+		length, unit = "len([]rune("+value+"))", " characters long"
+	case "slice":
+		length, unit = "len("+value+")", " items long"
+	}
+	if len(f.Tags.Min) > 0 {
+		checkSet(length+" < "+f.Tags.Min, "must be at least "+f.Tags.Min+unit)
+	}
+	if len(f.Tags.Max) > 0 {
+		checkSet(length+" > "+f.Tags.Max, "must be at most "+f.Tags.Max+unit)
+	}
+
+	if len(f.Tags.Pattern) > 0 {
+		checkSet(fmt.Sprintf("!matchesPattern(%v, %q)", value, f.Tags.Pattern), "must match "+f.Tags.Pattern)
+	}
+
+	if len(f.Tags.Enum) > 0 {
+		quoted := []string{}
+		for _, option := range f.Tags.Enum {
+			quoted = append(quoted, strconv.Quote(option))
+		}
+		checkSet(fmt.Sprintf("!isOneOf(%v, %v)", value, strings.Join(quoted, ", ")),
+			"must be one of "+strings.Join(f.Tags.Enum, ", "))
+	}
+
+	return buf.String()
+}
+
+// openAPIRules returns the keywords of OpenAPI schemas checking the validation
+// rules of the field, like "maxLength: 50"
+func (f *TypeField) openAPIRules() []string {
+	minimum, maximum := "minimum", "maximum"
+	switch ruleTarget(f.Type) {
+	case "string":
+		minimum, maximum = "minLength", "maxLength"
+	case "slice":
+		minimum, maximum = "minItems", "maxItems"
+	}
+
+	rules := []string{}
+	if len(f.Tags.Min) > 0 {
+		rules = append(rules, minimum+": "+f.Tags.Min)
+	}
+	if len(f.Tags.Max) > 0 {
+		rules = append(rules, maximum+": "+f.Tags.Max)
+	}
+	if len(f.Tags.Pattern) > 0 {
+		rules = append(rules, "pattern: "+strconv.Quote(f.Tags.Pattern))
+	}
+	if len(f.Tags.Enum) > 0 {
+		quoted := []string{}
+		for _, option := range f.Tags.Enum {
+			quoted = append(quoted, strconv.Quote(option))
+		}
+		rules = append(rules, "enum: ["+strings.Join(quoted, ", ")+"]")
+	}
+	return rules
+}
+
+// RequiredFields returns the fields clients must set, tagged as required, of
+// the ones serialized as json
+func (holder *TypeHolder) RequiredFields() []TypeField {
+	fields := []TypeField{}
+	for _, field := range holder.Fields {
+		if field.Tags.Required && field.JSONName() != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// sampleString returns a Go expression of a string matching the validation
+// rules of the field, or empty if default samples already match them
+func (f *TypeField) sampleString() string {
+	if len(f.Tags.Enum) > 0 {
+		quoted := []string{}
+		for _, option := range f.Tags.Enum {
+			quoted = append(quoted, strconv.Quote(option))
+		}
+		return fmt.Sprintf("[]string{%v}[n%%%v]", strings.Join(quoted, ", "), len(quoted))
+	}
+
+	if len(f.Tags.Pattern) > 0 {
+		return strconv.Quote(patternExample(f.Tags.Pattern))
+	}
+
+	// default samples, like "Name 1", are as long as the name plus two
+	minimum, _ := strconv.Atoi(f.Tags.Min)
+	maximum, err := strconv.Atoi(f.Tags.Max)
+	switch {
+	case minimum > len(f.Name)+2:
+		return strconv.Quote(strings.Repeat("x", minimum))
+	case err == nil && maximum < len(f.Name)+2:
+		return strconv.Quote(strings.Repeat("x", maximum))
+	}
+	return ""
+}
+
+// sampleNumber returns a Go expression of a number matching the bounds of
+// the field, or empty if default samples, from 1 to 3.5, already match them
+func (f *TypeField) sampleNumber(t string) string {
+	minimum, err := strconv.ParseFloat(f.Tags.Min, 64)
+	if err == nil && minimum > 1 {
+		return t + "(" + f.Tags.Min + ")"
+	}
+	maximum, err := strconv.ParseFloat(f.Tags.Max, 64)
+	if err == nil && maximum < 3.5 {
+		return t + "(" + f.Tags.Max + ")"
+	}
+	return ""
+}
+
+// patternExample returns a short string matching a regular expression
+func patternExample(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	writeExample(&buf, re.Simplify())
+	return buf.String()
+}
+
+func writeExample(buf *bytes.Buffer, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		buf.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		// prefer letters or digits inside the first range
+		r := re.Rune[0]
+		for _, candidate := range "aA0" {
+			if candidate >= re.Rune[0] && candidate <= re.Rune[1] {
+				r = candidate
+				break
+			}
+		}
+		buf.WriteRune(r)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		buf.WriteRune('x')
+	case syntax.OpCapture, syntax.OpPlus:
+		writeExample(buf, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			writeExample(buf, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeExample(buf, sub)
+		}
+	case syntax.OpAlternate:
+		writeExample(buf, re.Sub[0])
+	}
+}
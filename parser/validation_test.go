@@ -0,0 +1,147 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type ValidationSuite struct{}
+
+var _ = check.Suite(&ValidationSuite{})
+
+func (s *ValidationSuite) TestCheckRules(c *check.C) {
+	valid := []TypeField{
+		{Name: "F", Type: "string", Tags: FieldTags{Min: "1", Max: "5", Pattern: "a", Enum: []string{"a"}}},
+		{Name: "F", Type: "*sql.NullString", Tags: FieldTags{Pattern: "a"}},
+		{Name: "F", Type: "[]int", Tags: FieldTags{Max: "3"}},
+		{Name: "F", Type: "float64", Tags: FieldTags{Min: "0.5"}},
+		{Name: "F", Type: "bool", Tags: FieldTags{Required: true}},
+	}
+	for _, f := range valid {
+		c.Assert(f.checkRules(), check.IsNil, check.Commentf("type %v", f.Type))
+	}
+
+	invalid := map[string]TypeField{
+		"Field F: min and max do not apply to bool type":  {Name: "F", Type: "bool", Tags: FieldTags{Min: "1"}},
+		"Field F: pattern and enum only apply to strings": {Name: "F", Type: "int", Tags: FieldTags{Enum: []string{"1"}}},
+		"Field F: 0.5 is not an integer bound":            {Name: "F", Type: "string", Tags: FieldTags{Max: "0.5"}},
+	}
+	for message, f := range invalid {
+		c.Assert(f.checkRules(), check.ErrorMatches, message)
+	}
+}
+
+func (s *ValidationSuite) TestValidationCode(c *check.C) {
+	f := TypeField{
+		Name: "Name",
+		Type: "string",
+		Tags: FieldTags{JSON: "name", Required: true, Max: "5", Enum: []string{"a", "b"}},
+	}
+	c.Assert(f.ValidationCode("t"), check.Equals, `	if t.Name == "" {
+		fieldErrors = append(fieldErrors, FieldError{Field: "name", Message: "is required"})
+	}
+	if t.Name != "" && len([]rune(t.Name)) > 5 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "name", Message: "must be at most 5 characters long"})
+	}
+	if t.Name != "" && !isOneOf(t.Name, "a", "b") {
+		fieldErrors = append(fieldErrors, FieldError{Field: "name", Message: "must be one of a, b"})
+	}
+`)
+}
+
+func (s *ValidationSuite) TestValidationCode_optional(c *check.C) {
+	f := TypeField{Name: "Count", Type: "*int", Tags: FieldTags{Required: true, Min: "1"}}
+	c.Assert(f.ValidationCode("t"), check.Equals, `	if t.Count == nil {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Count", Message: "is required"})
+	}
+	if t.Count != nil && *t.Count < 1 {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Count", Message: "must be at least 1"})
+	}
+`)
+
+	f = TypeField{Name: "Code", Type: "sql.NullString", Tags: FieldTags{Pattern: "^[A-Z]+$"}}
+	c.Assert(f.ValidationCode("t"), check.Equals, `	if t.Code.Valid && !matchesPattern(t.Code.String, "^[A-Z]+$") {
+		fieldErrors = append(fieldErrors, FieldError{Field: "Code", Message: "must match ^[A-Z]+$"})
+	}
+`)
+}
+
+func (s *ValidationSuite) TestValidationCode_noRules(c *check.C) {
+	f := TypeField{Name: "Name", Type: "string"}
+	c.Assert(f.ValidationCode("t"), check.Equals, "")
+}
+
+func (s *ValidationSuite) TestSampleValue(c *check.C) {
+	samples := map[string]TypeField{
+		`[]string{"a", "b"}[n%2]`:          {Name: "F", Type: "string", Tags: FieldTags{Enum: []string{"a", "b"}}},
+		`"xxxxxxxxxx"`:                     {Name: "F", Type: "string", Tags: FieldTags{Min: "10"}},
+		`"xx"`:                             {Name: "F", Type: "string", Tags: FieldTags{Max: "2"}},
+		`fmt.Sprintf("F %d", n)`:           {Name: "F", Type: "string", Tags: FieldTags{Min: "2", Max: "10"}},
+		`"ab-00"`:                          {Name: "F", Type: "string", Tags: FieldTags{Pattern: `^(ab|cd)-\d{2,4}$`}},
+		"int64(10)":                        {Name: "F", Type: "int64", Tags: FieldTags{Min: "10"}},
+		"float64(2.5)":                     {Name: "F", Type: "float64", Tags: FieldTags{Max: "2.5"}},
+		`[]string{fmt.Sprintf("F %d", n)}`: {Name: "F", Type: "[]string", Tags: FieldTags{Max: "2"}},
+		`func() *int { v := int(5); return &v }()`: {Name: "F", Type: "*int", Tags: FieldTags{Min: "5"}},
+	}
+	for sample, f := range samples {
+		c.Assert(f.SampleValue(), check.Equals, sample, check.Commentf("field %+v", f))
+	}
+}
+
+func (s *ValidationSuite) TestOpenAPIPropertySchema(c *check.C) {
+	schemas := map[string]TypeField{
+		`{type: string, maxLength: 50, enum: ["a", "b"]}`:         {Name: "F", Type: "string", Tags: FieldTags{Max: "50", Enum: []string{"a", "b"}}},
+		`{type: string, pattern: "^\\d+$", nullable: true}`:       {Name: "F", Type: "*string", Tags: FieldTags{Pattern: `^\d+$`}},
+		"{type: integer, format: int64, minimum: 1, maximum: 10}": {Name: "F", Type: "int64", Tags: FieldTags{Min: "1", Max: "10"}},
+		"{type: array, items: {type: string}, minItems: 1}":       {Name: "F", Type: "[]string", Tags: FieldTags{Min: "1"}},
+		"{type: object, properties: {String: {type: string, minLength: 2}, Valid: {type: boolean}}}": {
+			Name: "F", Type: "sql.NullString", Tags: FieldTags{Min: "2"}},
+		"{type: string}": {Name: "F", Type: "string", Tags: FieldTags{Required: true}},
+	}
+	for schema, f := range schemas {
+		c.Assert(f.OpenAPIPropertySchema(), check.Equals, schema, check.Commentf("field %+v", f))
+	}
+}
+
+func (s *ValidationSuite) TestRequiredFields(c *check.C) {
+	holder := &TypeHolder{Fields: []TypeField{
+		{Name: "ID", Type: "int"},
+		{Name: "Name", Type: "string", Tags: FieldTags{Required: true}},
+		{Name: "Secret", Type: "string", Tags: FieldTags{Required: true, JSON: "-"}},
+		{Name: "Kind", Type: "string", Tags: FieldTags{Enum: []string{"a", "b"}}},
+	}}
+
+	fields := holder.RequiredFields()
+	c.Assert(fields, check.HasLen, 1)
+	c.Assert(fields[0].Name, check.Equals, "Name")
+}
+
+func (s *ValidationSuite) TestPatternExample(c *check.C) {
+	examples := map[string]string{
+		`^[a-z]+$`:            "a",
+		`^[A-Z]{3}-[0-9]{2}$`: "AAA-00",
+		`^\w+@\w+\.com$`:      "0@0.com",
+		`(foo|bar)?baz`:       "baz",
+	}
+	for pattern, example := range examples {
+		c.Assert(patternExample(pattern), check.Equals, example, check.Commentf("pattern %v", pattern))
+	}
+}
@@ -0,0 +1,32 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+// VersionColumn holds the version of every register, starting by 1 and
+// increased by the datastore on every change, to detect concurrent ones
+const VersionColumn = "row_version"
+
+// versionColumn returns the column holding the version of the registers
+func (holder *TypeHolder) versionColumn() Column {
+	return Column{
+		Name:       VersionColumn,
+		Definition: VersionColumn + " " + holder.Dialect.SQLType("int") + " not null default 1",
+	}
+}
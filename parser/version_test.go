@@ -0,0 +1,55 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2018 Roberto Mier Escandon <rmescandon@gmail.com>
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package parser
+
+import (
+	check "gopkg.in/check.v1"
+)
+
+type VersionSuite struct{}
+
+var _ = check.Suite(&VersionSuite{})
+
+func (s *VersionSuite) TestColumns(c *check.C) {
+	holder := &TypeHolder{
+		Name:    "Book",
+		Dialect: Postgres,
+		Fields: []TypeField{
+			{Name: "ID", Type: "int64"},
+			{Name: "Title", Type: "string"},
+		},
+	}
+	columns := holder.Columns()
+	c.Assert(columns, check.HasLen, 3)
+	c.Assert(columns[2], check.DeepEquals, Column{Name: "row_version", Definition: "row_version integer not null default 1"})
+}
+
+func (s *VersionSuite) TestComposeTypeHolders_versionColumnTaken(c *check.C) {
+	_, err := composeTestTypeHolders(c, `
+	package model
+
+	type Book struct {
+		ID         int
+		Title      string
+		RowVersion int `+"`db:\"row_version\"`"+`
+	}
+	`)
+	c.Assert(err, check.ErrorMatches, "Field RowVersion of type Book cannot be stored in column row_version, kept for versions")
+}
@@ -40,7 +40,7 @@ const (
 		Name          string
 		Description   string
 		TheBoolThing  bool
-		TheFloatThing float
+		TheFloatThing float32
 	}	
 	`
 
@@ -53,7 +53,7 @@ const (
 		AName          string
 		ADescription   string
 		ABoolThing     bool
-		AFloatingThing float
+		AFloatingThing float32
 	}	
 	`
 